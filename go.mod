@@ -18,6 +18,7 @@ require (
 	golang.org/x/net v0.0.0-20200927032502-5d4f70055728 // indirect
 	gopkg.in/yaml.v2 v2.4.0
 	istio.io/client-go v1.8.0
+	k8s.io/api v0.18.12
 	k8s.io/apimachinery v0.18.12
 	k8s.io/client-go v0.18.12
 )
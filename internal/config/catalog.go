@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-adapter-library/config"
+)
+
+const (
+	// advertisedReleases is the number of istio versions offered by the
+	// install and upgrade operations
+	advertisedReleases = 3
+
+	// OperationsRefreshInterval is the time between two refreshes of the
+	// versions advertised by the operations
+	OperationsRefreshInterval = time.Hour
+
+	// defaultRelease is the istio release advertised until the release
+	// metadata has been fetched once, so that installs work on a first run
+	defaultRelease adapter.Version = "1.8.1"
+)
+
+// versionedOperations are the operations advertising the istio versions
+var versionedOperations = []string{
+	IstioOperation,
+	IstioUpgradeOperation,
+	IstioCanaryUpgradeOperation,
+}
+
+// cachedReleaseNames returns the names of the latest releases known to the
+// release cache, without reaching out to the network. The default release
+// is returned when nothing has been cached yet
+func cachedReleaseNames(limit int) []adapter.Version {
+	for _, name := range []string{githubReleasesFile, ReleaseMetadataFile} {
+		// The cache location is controlled by the adapter configuration, hence
+		// #nosec
+		body, err := ioutil.ReadFile(filepath.Join(ReleaseCachePath(), name))
+		if err != nil {
+			continue
+		}
+
+		var releases []*Release
+		if err := json.Unmarshal(body, &releases); err != nil {
+			continue
+		}
+
+		if versions := selectReleaseVersions(releases, ReleaseFilterFromEnv(), limit); len(versions) > 0 {
+			return versions
+		}
+	}
+
+	return []adapter.Version{defaultRelease}
+}
+
+// RefreshOperations keeps the istio versions advertised by the operations of
+// the handler up to date. The release metadata is fetched right away and then
+// every interval, failures are passed to onError and retried on the next tick.
+// It never returns, hence is meant to run in its own goroutine
+func RefreshOperations(handler config.Handler, interval time.Duration, onError func(error)) {
+	for {
+		if err := refreshOperationVersions(handler); err != nil && onError != nil {
+			onError(err)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// refreshOperationVersions replaces the versions of the versioned operations
+// stored in the handler, and in the catalog returned by Operations, with the
+// latest release names
+func refreshOperationVersions(handler config.Handler) error {
+	versions, err := getLatestReleaseNames(advertisedReleases)
+	if err != nil {
+		return ErrRefreshOperations(err)
+	}
+	if len(versions) == 0 {
+		return ErrRefreshOperations(fmt.Errorf("no istio releases found"))
+	}
+
+	operations := make(adapter.Operations)
+	if err := handler.GetObject(adapter.OperationsKey, &operations); err != nil {
+		return ErrRefreshOperations(err)
+	}
+
+	if err := handler.SetObject(adapter.OperationsKey, withVersions(operations, versions)); err != nil {
+		return ErrRefreshOperations(err)
+	}
+	setOperationVersions(versions)
+
+	return nil
+}
+
+// withVersions returns a copy of the operations where the versioned ones
+// advertise the given versions, the operations passed in are left untouched
+// as they may be in use
+func withVersions(operations adapter.Operations, versions []adapter.Version) adapter.Operations {
+	updated := make(adapter.Operations, len(operations))
+	for name, op := range operations {
+		updated[name] = op
+	}
+
+	for _, name := range versionedOperations {
+		if op, ok := updated[name]; ok && op != nil {
+			versioned := *op
+			versioned.Versions = versions
+			updated[name] = &versioned
+		}
+	}
+
+	return updated
+}
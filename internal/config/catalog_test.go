@@ -0,0 +1,129 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	configprovider "github.com/layer5io/meshery-adapter-library/config/provider"
+)
+
+const catalogReleases = `[
+	{"tag_name": "1.8.0", "name": "Istio 1.8.0"},
+	{"tag_name": "1.9.0-rc.1", "name": "Istio 1.9.0-rc.1"},
+	{"tag_name": "1.8.1", "name": "Istio 1.8.1"},
+	{"tag_name": "1.7.6", "name": "Istio 1.7.6"}
+]`
+
+func Test_cachedReleaseNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []adapter.Version
+	}{
+		{
+			name: "nothing cached",
+			want: []adapter.Version{defaultRelease},
+		},
+		{
+			name:  "github metadata",
+			files: map[string]string{githubReleasesFile: catalogReleases},
+			want:  []adapter.Version{"1.8.1", "1.8.0", "1.7.6"},
+		},
+		{
+			name:  "mirror metadata",
+			files: map[string]string{ReleaseMetadataFile: `[{"tag_name": "1.7.5"}]`},
+			want:  []adapter.Version{"1.7.5"},
+		},
+		{
+			name: "invalid github metadata falls back to the mirror metadata",
+			files: map[string]string{
+				githubReleasesFile:  "{",
+				ReleaseMetadataFile: `[{"tag_name": "1.7.5"}]`,
+			},
+			want: []adapter.Version{"1.7.5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cacheDir, cleanup := setupReleaseDirs(t, func(dir string) string { return "file://" + dir })
+			defer cleanup()
+
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(cacheDir, name), content)
+			}
+
+			if got := cachedReleaseNames(advertisedReleases); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cachedReleaseNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_refreshOperationVersions(t *testing.T) {
+	mirrorDir, _, cleanup := setupReleaseDirs(t, func(dir string) string { return "file://" + dir })
+	defer cleanup()
+
+	handler, err := configprovider.NewInMem(configprovider.Options{
+		Operations: adapter.Operations{
+			IstioOperation:        &adapter.Operation{Description: "Istio Service Mesh"},
+			IstioUpgradeOperation: &adapter.Operation{Description: "Istio In-place Upgrade"},
+			LabelNamespace:        &adapter.Operation{Description: "Automatic Sidecar Injection"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := refreshOperationVersions(handler); err == nil {
+		t.Error("refreshOperationVersions() expected an error without release metadata")
+	}
+
+	writeFile(t, filepath.Join(mirrorDir, ReleaseMetadataFile), catalogReleases)
+	if err := refreshOperationVersions(handler); err != nil {
+		t.Fatalf("refreshOperationVersions() error = %v", err)
+	}
+
+	operations := make(adapter.Operations)
+	if err := handler.GetObject(adapter.OperationsKey, &operations); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []adapter.Version{"1.8.1", "1.8.0", "1.7.6"}
+	for _, name := range []string{IstioOperation, IstioUpgradeOperation} {
+		if got := operations[name].Versions; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s versions = %v, want %v", name, got, want)
+		}
+	}
+	if got := operations[LabelNamespace].Versions; len(got) != 0 {
+		t.Errorf("%s versions = %v, want none", LabelNamespace, got)
+	}
+	if _, ok := operations[IstioCanaryUpgradeOperation]; ok {
+		t.Errorf("%s should not be added", IstioCanaryUpgradeOperation)
+	}
+
+	for _, name := range versionedOperations {
+		if got := Operations()[name].Versions; !reflect.DeepEqual(got, want) {
+			t.Errorf("catalog %s versions = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func Test_withVersions(t *testing.T) {
+	install := &adapter.Operation{Description: "Istio Service Mesh", Versions: []adapter.Version{"1.7.6"}}
+	label := &adapter.Operation{Description: "Automatic Sidecar Injection"}
+	operations := adapter.Operations{IstioOperation: install, LabelNamespace: label}
+
+	got := withVersions(operations, []adapter.Version{"1.8.1"})
+
+	if want := []adapter.Version{"1.8.1"}; !reflect.DeepEqual(got[IstioOperation].Versions, want) {
+		t.Errorf("withVersions() %s versions = %v, want %v", IstioOperation, got[IstioOperation].Versions, want)
+	}
+	if got[LabelNamespace] != label {
+		t.Errorf("withVersions() should keep the unversioned operations")
+	}
+	if want := []adapter.Version{"1.7.6"}; !reflect.DeepEqual(install.Versions, want) {
+		t.Errorf("withVersions() modified the operations passed in, versions = %v", install.Versions)
+	}
+}
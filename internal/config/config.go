@@ -2,7 +2,9 @@ package config
 
 import (
 	"path"
+	"sync"
 
+	"github.com/layer5io/meshery-adapter-library/adapter"
 	"github.com/layer5io/meshery-adapter-library/common"
 	"github.com/layer5io/meshery-adapter-library/config"
 	configprovider "github.com/layer5io/meshery-adapter-library/config/provider"
@@ -16,10 +18,33 @@ const (
 	IstioOperation = "istio"
 	LabelNamespace = "label-namespace"
 
-	ServicePatchFile = "service-patch-file"
-	CPPatchFile      = "cp-patch-file"
-	ControlPatchFile = "control-patch-file"
-	FilterPatchFile  = "filter-patch-file"
+	// Revision based (canary) upgrade of the control plane
+	IstioCanaryUpgradeOperation = "istio-canary-upgrade"
+
+	// In-place upgrade of the control plane
+	IstioUpgradeOperation = "istio-upgrade"
+
+	// Time the control plane or an addon gets to become ready after the install
+	ReadinessTimeout = "readiness-timeout"
+
+	// Template of the istio operator manifests used by the operator installer
+	OperatorManifestFile = "operator-manifest-file"
+
+	FilterPatchFile = "filter-patch-file"
+
+	// Cached istioctl binaries operations
+	IstioctlCacheListOperation  = "list-istioctl-cache"
+	IstioctlCachePruneOperation = "prune-istioctl-cache"
+
+	// Cache policy of the istioctl binaries
+	CacheMaxEntries = "max-entries"
+	CacheMaxBytes   = "max-bytes"
+
+	// Cancels an in-flight operation, the custom body holds its operation id
+	CancelOperation = "cancel-operation"
+
+	// Timeout of an operation, as a duration like "20m"
+	OperationTimeout = "timeout"
 
 	// Istio vet operation
 	IstioVetOperation = "istio-vet"
@@ -27,6 +52,20 @@ const (
 	// Configure Envoy filter operation
 	EnvoyFilterOperation = "envoy-filter-operation"
 
+	// Path of the addon manifest in the istio repository, fetched from the tag
+	// of the istio release with the bundled template as offline fallback
+	AddonManifest = "addon-manifest"
+
+	// Default exposure mode of an addon: ClusterIP, NodePort, LoadBalancer or Gateway
+	AddonExposure = "addon-exposure"
+
+	// Comma separated names of the CRDs installed with an addon, removed on
+	// uninstall only when requested as other workloads may still use them
+	AddonCRDs = "addon-crds"
+
+	// Reports the health and the endpoint of every addon
+	AddonStatusOperation = "addon-status"
+
 	// Addons that the adapter supports
 	PrometheusAddon = "prometheus-addon"
 	GrafanaAddon    = "grafana-addon"
@@ -50,7 +89,6 @@ var (
 		ServerConfig:   ServerConfig,
 		MeshSpec:       MeshSpec,
 		ProviderConfig: ProviderConfig,
-		Operations:     Operations,
 	}
 
 	ServerConfig = map[string]string{
@@ -61,9 +99,10 @@ var (
 	}
 
 	MeshSpec = map[string]string{
-		"name":    smp.ServiceMesh_ISTIO.Enum().String(),
-		"status":  status.NotInstalled,
-		"version": status.None,
+		"name":      smp.ServiceMesh_ISTIO.Enum().String(),
+		"status":    status.NotInstalled,
+		"version":   status.None,
+		"revisions": status.None,
 	}
 
 	ProviderConfig = map[string]string{
@@ -79,17 +118,48 @@ var (
 		configprovider.FileName: "kubeconfig",
 	}
 
-	Operations = getOperations(common.Operations)
+	operationsOnce sync.Once
+	operationsMu   sync.RWMutex
+	operations     adapter.Operations
 )
 
+// Operations returns the operation catalog of the adapter. It is built on
+// first use from the release metadata known to the release cache, without
+// reaching out to the network, the advertised versions are kept up to date
+// here and in the config handler by RefreshOperations
+func Operations() adapter.Operations {
+	operationsOnce.Do(func() {
+		operations = getOperations(common.Operations)
+	})
+
+	operationsMu.RLock()
+	defer operationsMu.RUnlock()
+
+	return operations
+}
+
+// setOperationVersions replaces the catalog with a copy advertising the
+// given versions for the versioned operations
+func setOperationVersions(versions []adapter.Version) {
+	Operations()
+
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+
+	operations = withVersions(operations, versions)
+}
+
 // New creates a new config instance
 func New(provider string) (config.Handler, error) {
+	opts := Config
+	opts.Operations = Operations()
+
 	// Config provider
 	switch provider {
 	case configprovider.ViperKey:
-		return configprovider.NewViper(Config)
+		return configprovider.NewViper(opts)
 	case configprovider.InMemKey:
-		return configprovider.NewInMem(Config)
+		return configprovider.NewInMem(opts)
 	}
 
 	return nil, ErrEmptyConfig
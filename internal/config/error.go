@@ -24,6 +24,9 @@ const (
 	ErrEmptyConfigCode           = "11300"
 	ErrGetLatestReleasesCode     = "istio_test_code"
 	ErrGetLatestReleaseNamesCode = "istio_test_code"
+	ErrOpenReleaseAssetCode      = "istio_test_code"
+	ErrRefreshOperationsCode     = "istio_test_code"
+	ErrReleaseNotFoundCode       = "istio_test_code"
 )
 
 var (
@@ -39,3 +42,18 @@ func ErrGetLatestReleases(err error) error {
 func ErrGetLatestReleaseNames(err error) error {
 	return errors.NewDefault(ErrGetLatestReleaseNamesCode, fmt.Sprintf("failed to extract release names: %s", err.Error()))
 }
+
+// ErrOpenReleaseAsset is the error for fetching istio release assets
+func ErrOpenReleaseAsset(err error) error {
+	return errors.NewDefault(ErrOpenReleaseAssetCode, fmt.Sprintf("unable to fetch release asset: %s", err.Error()))
+}
+
+// ErrRefreshOperations is the error for refreshing the versions advertised by the operations
+func ErrRefreshOperations(err error) error {
+	return errors.NewDefault(ErrRefreshOperationsCode, fmt.Sprintf("unable to refresh the istio versions of the operations: %s", err.Error()))
+}
+
+// ErrReleaseNotFound is the error for a release missing from the release metadata
+func ErrReleaseNotFound(version string) error {
+	return errors.NewDefault(ErrReleaseNotFoundCode, fmt.Sprintf("istio release %s not found in the release metadata", version))
+}
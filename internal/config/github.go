@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GithubTokenEnv is the environment variable holding an optional github
+	// token, which raises the rate limit of the release metadata requests
+	GithubTokenEnv = "GITHUB_TOKEN"
+
+	// ReleaseMetadataTTLEnv is the environment variable used to override the
+	// time the release metadata fetched from github is used without revalidation
+	ReleaseMetadataTTLEnv = "ISTIO_RELEASE_METADATA_TTL"
+
+	defaultReleaseMetadataTTL = time.Hour
+
+	// githubReleasesFile caches the release metadata fetched from github
+	// in the release cache, next to its ETag and rate limit reset time
+	githubReleasesFile = "github-releases.json"
+)
+
+// githubReleasesURL is the github API endpoint listing the istio releases
+var githubReleasesURL = "https://api.github.com/repos/istio/istio/releases?per_page=100"
+
+var githubClient = &http.Client{Timeout: 30 * time.Second}
+
+// releaseMetadataTTL returns the time the cached release metadata is fresh for
+func releaseMetadataTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(ReleaseMetadataTTLEnv)); err == nil && d >= 0 {
+		return d
+	}
+
+	return defaultReleaseMetadataTTL
+}
+
+// fetchGithubReleases returns the istio release metadata of the github API.
+// The response is cached on disk and used as is while fresh, then revalidated
+// using its ETag. The last known metadata is returned when github can't be
+// reached or the rate limit is exceeded, in which case github isn't asked
+// again before the limit resets
+func fetchGithubReleases() ([]byte, error) {
+	cacheFile := filepath.Join(ReleaseCachePath(), githubReleasesFile)
+	etagFile := cacheFile + ".etag"
+	retryFile := cacheFile + ".retry"
+
+	// The cache location is controlled by the adapter configuration, hence
+	// #nosec
+	cached, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		cached = nil
+	}
+	if info, err := os.Stat(cacheFile); err == nil && cached != nil && time.Since(info.ModTime()) < releaseMetadataTTL() {
+		return cached, nil
+	}
+
+	if retryAt := readRetryTime(retryFile); cached != nil && time.Now().Before(retryAt) {
+		return cached, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, githubReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if token := os.Getenv(GithubTokenEnv); token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	if cached != nil {
+		// #nosec
+		if etag, err := ioutil.ReadFile(etagFile); err == nil {
+			req.Header.Set("If-None-Match", strings.TrimSpace(string(etag)))
+		}
+	}
+
+	resp, err := githubClient.Do(req)
+	if err != nil {
+		return lastKnown(cached, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		now := time.Now()
+		_ = os.Chtimes(cacheFile, now, now)
+		return cached, nil
+	case resp.StatusCode == http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return lastKnown(cached, err)
+		}
+		if !json.Valid(body) {
+			return lastKnown(cached, fmt.Errorf("invalid release metadata received from %s", githubReleasesURL))
+		}
+		storeGithubReleases(cacheFile, body, resp.Header.Get("ETag"))
+		_ = os.Remove(retryFile)
+		return body, nil
+	case isRateLimited(resp):
+		reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		_ = writeCacheFile(retryFile, []byte(strconv.FormatInt(reset, 10)))
+		return lastKnown(cached, fmt.Errorf("github rate limit exceeded until %s", time.Unix(reset, 0).Format(time.RFC3339)))
+	}
+
+	return lastKnown(cached, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, githubReleasesURL))
+}
+
+// lastKnown returns the cached metadata if there is any, err otherwise
+func lastKnown(cached []byte, err error) ([]byte, error) {
+	if cached != nil {
+		return cached, nil
+	}
+
+	return nil, err
+}
+
+// isRateLimited reports whether github refused the request due to the rate limit
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	return resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// readRetryTime returns the time the rate limit recorded in the file resets
+func readRetryTime(retryFile string) time.Time {
+	// #nosec
+	content, err := ioutil.ReadFile(retryFile)
+	if err != nil {
+		return time.Time{}
+	}
+
+	reset, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(reset, 0)
+}
+
+// storeGithubReleases caches the metadata along with its ETag, failures
+// only cost a request the next time
+func storeGithubReleases(cacheFile string, body []byte, etag string) {
+	if err := writeCacheFile(cacheFile, body); err != nil {
+		return
+	}
+
+	if etag == "" {
+		_ = os.Remove(cacheFile + ".etag")
+		return
+	}
+	_ = writeCacheFile(cacheFile+".etag", []byte(etag))
+}
+
+// writeCacheFile replaces the content of a file of the release cache
+func writeCacheFile(name string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
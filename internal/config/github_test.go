@@ -0,0 +1,119 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// githubStub serves release metadata like the github API does
+type githubStub struct {
+	requests    int
+	token       string
+	ifNoneMatch string
+	status      int
+}
+
+func (g *githubStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.requests++
+	g.token = r.Header.Get("Authorization")
+	g.ifNoneMatch = r.Header.Get("If-None-Match")
+
+	switch {
+	case g.status == http.StatusForbidden:
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	case r.Header.Get("If-None-Match") == `"v1"`:
+		w.WriteHeader(http.StatusNotModified)
+	default:
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"tag_name": "1.8.1", "name": "Istio 1.8.1"}]`))
+	}
+}
+
+func setupGithubStub(t *testing.T, ttl string) (*githubStub, *httptest.Server, func()) {
+	_, _, cleanup := setupReleaseDirs(t, func(dir string) string { return "" })
+
+	stub := &githubStub{}
+	server := httptest.NewServer(stub)
+
+	prevURL, prevTTL, prevToken := githubReleasesURL, os.Getenv(ReleaseMetadataTTLEnv), os.Getenv(GithubTokenEnv)
+	githubReleasesURL = server.URL
+	_ = os.Setenv(ReleaseMetadataTTLEnv, ttl)
+	_ = os.Setenv(GithubTokenEnv, "secret")
+
+	return stub, server, func() {
+		server.Close()
+		githubReleasesURL = prevURL
+		_ = os.Setenv(ReleaseMetadataTTLEnv, prevTTL)
+		_ = os.Setenv(GithubTokenEnv, prevToken)
+		cleanup()
+	}
+}
+
+func TestGetLatestReleases_github(t *testing.T) {
+	stub, server, cleanup := setupGithubStub(t, "1h")
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		releases, err := GetLatestReleases(20)
+		if err != nil {
+			t.Fatalf("GetLatestReleases() error = %v", err)
+		}
+		if len(releases) != 1 || releases[0].TagName != "1.8.1" {
+			t.Errorf("GetLatestReleases() = %+v, want the 1.8.1 release", releases)
+		}
+	}
+	if stub.requests != 1 {
+		t.Errorf("github was asked %d times, want the cached metadata to be reused", stub.requests)
+	}
+	if stub.token != "token secret" {
+		t.Errorf("Authorization header = %q, want the github token", stub.token)
+	}
+
+	// Unreachable github falls back to the last known metadata
+	server.Close()
+	_ = os.Setenv(ReleaseMetadataTTLEnv, "0s")
+	releases, err := GetLatestReleases(20)
+	if err != nil || len(releases) != 1 {
+		t.Errorf("GetLatestReleases() = %+v, %v, want the last known releases", releases, err)
+	}
+}
+
+func TestGetLatestReleases_revalidation(t *testing.T) {
+	stub, _, cleanup := setupGithubStub(t, "0s")
+	defer cleanup()
+
+	if _, err := fetchGithubReleases(); err != nil {
+		t.Fatalf("fetchGithubReleases() error = %v", err)
+	}
+	if stub.ifNoneMatch != "" {
+		t.Errorf("If-None-Match = %s on the first request, want none", stub.ifNoneMatch)
+	}
+
+	if _, err := fetchGithubReleases(); err != nil {
+		t.Fatalf("fetchGithubReleases() error = %v", err)
+	}
+	if stub.ifNoneMatch != `"v1"` {
+		t.Errorf("If-None-Match = %s, want the ETag of the cached metadata", stub.ifNoneMatch)
+	}
+	if stub.requests != 2 {
+		t.Errorf("github was asked %d times, want every request to be revalidated", stub.requests)
+	}
+
+	// Once rate limited github isn't asked again before the limit resets
+	stub.status = http.StatusForbidden
+	for i := 0; i < 2; i++ {
+		body, err := fetchGithubReleases()
+		if err != nil || len(body) == 0 {
+			t.Fatalf("fetchGithubReleases() = %s, %v, want the last known metadata", body, err)
+		}
+	}
+	if stub.requests != 3 {
+		t.Errorf("github was asked %d times while rate limited, want 3", stub.requests)
+	}
+}
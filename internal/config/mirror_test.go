@@ -0,0 +1,196 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupReleaseDirs creates a mirror and a cache directory and points the
+// adapter at them, the returned function restores the environment
+func setupReleaseDirs(t *testing.T, mirror func(dir string) string) (string, string, func()) {
+	mirrorDir, err := ioutil.TempDir("", "istio-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheDir, err := ioutil.TempDir("", "istio-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevMirror, prevCache := os.Getenv(ReleaseMirrorEnv), os.Getenv(ReleaseCacheEnv)
+	_ = os.Setenv(ReleaseMirrorEnv, mirror(mirrorDir))
+	_ = os.Setenv(ReleaseCacheEnv, cacheDir)
+
+	return mirrorDir, cacheDir, func() {
+		_ = os.Setenv(ReleaseMirrorEnv, prevMirror)
+		_ = os.Setenv(ReleaseCacheEnv, prevCache)
+		_ = os.RemoveAll(mirrorDir)
+		_ = os.RemoveAll(cacheDir)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenReleaseAsset(t *testing.T) {
+	var server *httptest.Server
+	mirrorDir, cacheDir, cleanup := setupReleaseDirs(t, func(dir string) string {
+		server = httptest.NewServer(http.FileServer(http.Dir(dir)))
+		return server.URL
+	})
+	defer cleanup()
+	defer server.Close()
+
+	writeFile(t, filepath.Join(mirrorDir, "1.8.1", "istioctl-1.8.1-linux-amd64.tar.gz"), "from mirror")
+	writeFile(t, filepath.Join(mirrorDir, "1.8.0", "istioctl-1.8.0-linux-amd64.tar.gz"), "from mirror")
+	writeFile(t, filepath.Join(cacheDir, "1.8.0", "istioctl-1.8.0-linux-amd64.tar.gz"), "from cache")
+
+	tests := []struct {
+		name    string
+		release string
+		asset   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "served by the mirror",
+			release: "1.8.1",
+			asset:   "istioctl-1.8.1-linux-amd64.tar.gz",
+			want:    "from mirror",
+		},
+		{
+			name:    "served by the cache",
+			release: "1.8.0",
+			asset:   "istioctl-1.8.0-linux-amd64.tar.gz",
+			want:    "from cache",
+		},
+		{
+			name:    "missing asset",
+			release: "1.7.0",
+			asset:   "istioctl-1.7.0-linux-amd64.tar.gz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenReleaseAsset(context.Background(), tt.release, tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OpenReleaseAsset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			defer func() {
+				_ = got.Close()
+			}()
+
+			content, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("OpenReleaseAsset() = %v, want %v", string(content), tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenReleaseAsset_relativeMirror(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	mirrorDir, _, cleanup := setupReleaseDirs(t, func(dir string) string {
+		if err := os.Chdir(filepath.Dir(dir)); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + filepath.Base(dir)
+	})
+	defer cleanup()
+
+	writeFile(t, filepath.Join(mirrorDir, "1.8.1", "istioctl-1.8.1-linux-amd64.tar.gz"), "from mirror")
+
+	got, err := OpenReleaseAsset(context.Background(), "1.8.1", "istioctl-1.8.1-linux-amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("OpenReleaseAsset() error = %v", err)
+	}
+	defer func() {
+		_ = got.Close()
+	}()
+
+	content, err := ioutil.ReadAll(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "from mirror" {
+		t.Errorf("OpenReleaseAsset() = %v, want from mirror", string(content))
+	}
+}
+
+func TestGetLatestReleases_mirror(t *testing.T) {
+	mirrorDir, cacheDir, cleanup := setupReleaseDirs(t, func(dir string) string {
+		return "file://" + filepath.ToSlash(dir)
+	})
+	defer cleanup()
+
+	writeFile(t, filepath.Join(mirrorDir, ReleaseMetadataFile), `[{"tag_name": "1.8.1", "name": "Istio 1.8.1"}, {"tag_name": "1.8.0", "name": "Istio 1.8.0"}]`)
+	writeFile(t, filepath.Join(cacheDir, ReleaseMetadataFile), `[{"tag_name": "1.7.6", "name": "Istio 1.7.6"}]`)
+
+	releases, err := GetLatestReleases(1)
+	if err != nil {
+		t.Fatalf("GetLatestReleases() error = %v", err)
+	}
+	if len(releases) != 1 || releases[0].TagName != "1.8.1" {
+		t.Errorf("GetLatestReleases() = %+v, want the 1.8.1 release from the mirror", releases)
+	}
+
+	// Fall back to the cached metadata once the mirror is gone
+	if err := os.Remove(filepath.Join(mirrorDir, ReleaseMetadataFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err = GetLatestReleases(20)
+	if err != nil {
+		t.Fatalf("GetLatestReleases() error = %v", err)
+	}
+	if len(releases) != 1 || releases[0].TagName != "1.7.6" {
+		t.Errorf("GetLatestReleases() = %+v, want the 1.7.6 release from the cache", releases)
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	mirrorDir, _, cleanup := setupReleaseDirs(t, func(dir string) string {
+		return "file://" + filepath.ToSlash(dir)
+	})
+	defer cleanup()
+
+	writeFile(t, filepath.Join(mirrorDir, ReleaseMetadataFile), `[{"tag_name": "1.8.1", "name": "Istio 1.8.1"}]`)
+
+	release, err := GetRelease("v1.8.1")
+	if err != nil {
+		t.Fatalf("GetRelease() error = %v", err)
+	}
+	if release.TagName != "1.8.1" {
+		t.Errorf("GetRelease() = %+v, want the 1.8.1 release", release)
+	}
+
+	if release, err := GetRelease("1.7.6"); err == nil {
+		t.Errorf("GetRelease() = %+v, want an error for an unknown release", release)
+	}
+}
@@ -11,7 +11,7 @@ var (
 )
 
 func getOperations(dev adapter.Operations) adapter.Operations {
-	versions, _ := getLatestReleaseNames(3)
+	versions := cachedReleaseNames(advertisedReleases)
 
 	// Add Istio networking resources to sample applications
 	dev[common.BookInfoOperation].Templates = append(dev[common.BookInfoOperation].Templates, "file://templates/bookinfo/gateway.yaml")
@@ -20,10 +20,32 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 	dev[common.EmojiVotoOperation].Templates = append(dev[common.EmojiVotoOperation].Templates, "file://templates/emojivoto/gateway.yaml")
 
 	dev[IstioOperation] = &adapter.Operation{
-		Type:                 int32(meshes.OpCategory_INSTALL),
-		Description:          "Istio Service Mesh",
-		Versions:             versions,
-		AdditionalProperties: map[string]string{},
+		Type:        int32(meshes.OpCategory_INSTALL),
+		Description: "Istio Service Mesh",
+		Versions:    versions,
+		AdditionalProperties: map[string]string{
+			OperatorManifestFile: "file://templates/operator/operator.yaml",
+			OperationTimeout:     "20m",
+			ReadinessTimeout:     "5m",
+		},
+	}
+
+	dev[IstioUpgradeOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_INSTALL),
+		Description: "Istio In-place Upgrade",
+		Versions:    versions,
+		AdditionalProperties: map[string]string{
+			OperationTimeout: "20m",
+		},
+	}
+
+	dev[IstioCanaryUpgradeOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_INSTALL),
+		Description: "Istio Canary Upgrade",
+		Versions:    versions,
+		AdditionalProperties: map[string]string{
+			OperationTimeout: "30m",
+		},
 	}
 
 	dev[LabelNamespace] = &adapter.Operation{
@@ -35,11 +57,13 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Add-on: Prometheus",
 		Templates: []adapter.Template{
-			"https://raw.githubusercontent.com/istio/istio/master/samples/addons/prometheus.yaml",
+			"file://templates/addons/prometheus.yaml",
 		},
 		AdditionalProperties: map[string]string{
+			AddonManifest:    "samples/addons/prometheus.yaml",
 			ServiceName:      "prometheus",
-			ServicePatchFile: "file://templates/patches/service-loadbalancer.json",
+			AddonExposure:    "ClusterIP",
+			ReadinessTimeout: "5m",
 		},
 	}
 
@@ -47,11 +71,13 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Add-on: Grafana",
 		Templates: []adapter.Template{
-			"https://raw.githubusercontent.com/istio/istio/master/samples/addons/grafana.yaml",
+			"file://templates/addons/grafana.yaml",
 		},
 		AdditionalProperties: map[string]string{
+			AddonManifest:    "samples/addons/grafana.yaml",
 			ServiceName:      "grafana",
-			ServicePatchFile: "file://templates/patches/service-loadbalancer.json",
+			AddonExposure:    "ClusterIP",
+			ReadinessTimeout: "5m",
 		},
 	}
 
@@ -59,11 +85,14 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Add-on: Kiali",
 		Templates: []adapter.Template{
-			"https://raw.githubusercontent.com/istio/istio/master/samples/addons/kiali.yaml",
+			"file://templates/addons/kiali.yaml",
 		},
 		AdditionalProperties: map[string]string{
+			AddonManifest:    "samples/addons/kiali.yaml",
 			ServiceName:      "kiali",
-			ServicePatchFile: "file://templates/patches/service-loadbalancer.json",
+			AddonExposure:    "ClusterIP",
+			ReadinessTimeout: "5m",
+			AddonCRDs:        "monitoringdashboards.monitoring.kiali.io",
 		},
 	}
 
@@ -71,11 +100,13 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Add-on: Jaeger",
 		Templates: []adapter.Template{
-			"https://raw.githubusercontent.com/istio/istio/master/samples/addons/jaeger.yaml",
+			"file://templates/addons/jaeger.yaml",
 		},
 		AdditionalProperties: map[string]string{
+			AddonManifest:    "samples/addons/jaeger.yaml",
 			ServiceName:      "jaeger-collector",
-			ServicePatchFile: "file://templates/patches/service-loadbalancer.json",
+			AddonExposure:    "ClusterIP",
+			ReadinessTimeout: "5m",
 		},
 	}
 
@@ -83,19 +114,45 @@ func getOperations(dev adapter.Operations) adapter.Operations {
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Add-on: Zipkin",
 		Templates: []adapter.Template{
-			"https://raw.githubusercontent.com/istio/istio/master/samples/addons/extras/zipkin.yaml",
+			"file://templates/addons/zipkin.yaml",
 		},
 		AdditionalProperties: map[string]string{
+			AddonManifest:    "samples/addons/extras/zipkin.yaml",
 			ServiceName:      "zipkin",
-			ServicePatchFile: "file://templates/patches/service-loadbalancer.json",
+			AddonExposure:    "ClusterIP",
+			ReadinessTimeout: "5m",
 		},
 	}
 
+	dev[AddonStatusOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_VALIDATE),
+		Description: "Add-on Status",
+	}
+
 	dev[IstioVetOperation] = &adapter.Operation{
 		Type:        int32(meshes.OpCategory_VALIDATE),
 		Description: "Analyze Running Configuration",
 	}
 
+	dev[IstioctlCacheListOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_CONFIGURE),
+		Description: "List Cached istioctl Binaries",
+	}
+
+	dev[IstioctlCachePruneOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_CONFIGURE),
+		Description: "Prune Cached istioctl Binaries",
+		AdditionalProperties: map[string]string{
+			CacheMaxEntries: "5",
+			CacheMaxBytes:   "0",
+		},
+	}
+
+	dev[CancelOperation] = &adapter.Operation{
+		Type:        int32(meshes.OpCategory_CONFIGURE),
+		Description: "Cancel Running Operation",
+	}
+
 	dev[EnvoyFilterOperation] = &adapter.Operation{
 		Type:        int32(meshes.OpCategory_CONFIGURE),
 		Description: "Envoy Filter for Image Hub",
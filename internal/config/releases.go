@@ -1,12 +1,12 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
-	"regexp"
-	"sort"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/layer5io/meshery-adapter-library/adapter"
@@ -28,70 +28,89 @@ type Asset struct {
 	DownloadURL string `json:"browser_download_url,omitempty"`
 }
 
-// getLatestReleaseNames returns the names of the latest releases
-// limited by the "limit" parameter. It filters out all the alpha
-// rc releases and sorts the result lexographically (descending)
+// releaseMetadataCount is the number of releases looked at to find the
+// advertised ones, large enough to span a few minor versions
+const releaseMetadataCount = 100
+
+// getLatestReleaseNames returns the names of the latest releases limited by
+// the "limit" parameter, newest first in semantic version order. Pre-releases
+// and older patch releases are filtered out as configured by the environment
 func getLatestReleaseNames(limit int) ([]adapter.Version, error) {
-	releases, err := GetLatestReleases(20)
+	releases, err := GetLatestReleases(releaseMetadataCount)
 	if err != nil {
 		return []adapter.Version{}, ErrGetLatestReleaseNames(err)
 	}
 
-	// Filter out the rc and alpha releases
-	result := make([]adapter.Version, limit)
-	r, err := regexp.Compile(`Istio \d+(\.\d+){2,}$`)
-	if err != nil {
-		return []adapter.Version{}, ErrGetLatestReleaseNames(err)
-	}
+	return selectReleaseVersions(releases, ReleaseFilterFromEnv(), limit), nil
+}
 
-	for _, release := range releases {
-		releaseStr := string(release.Name)
-		versionStr := strings.Split(releaseStr, " ")[1]
-		if r.MatchString(releaseStr) {
-			result = append(result, adapter.Version(versionStr))
+// GetLatestReleases fetches the latest releases from the istio repository,
+// cached on disk as described by fetchGithubReleases. If a release mirror is
+// configured the release metadata is read from the mirror instead, and if
+// neither is reachable the metadata in the local release cache is used
+func GetLatestReleases(releases uint) ([]*Release, error) {
+	body, err := fetchReleaseMetadata()
+	if err != nil {
+		cached, cerr := ioutil.ReadFile(path.Join(ReleaseCachePath(), ReleaseMetadataFile))
+		if cerr != nil {
+			return []*Release{}, ErrGetLatestReleases(err)
 		}
+		body = cached
 	}
 
-	// Sort the result
-	sort.Slice(result, func(i, j int) bool {
-		return result[i] > result[j]
-	})
+	var releaseList []*Release
 
-	if limit > len(result) {
-		limit = len(result)
+	if err = json.Unmarshal(body, &releaseList); err != nil {
+		return []*Release{}, ErrGetLatestReleases(err)
 	}
 
-	return result[:limit], nil
+	if uint(len(releaseList)) > releases {
+		releaseList = releaseList[:releases]
+	}
+
+	return releaseList, nil
 }
 
-// GetLatestReleases fetches the latest releases from the istio repository
-func GetLatestReleases(releases uint) ([]*Release, error) {
-	releaseAPIURL := "https://api.github.com/repos/istio/istio/releases?per_page=" + fmt.Sprint(releases)
-	// We need a variable url here hence using nosec
-	// #nosec
-	resp, err := http.Get(releaseAPIURL)
+// GetRelease returns the metadata of the release with the given version,
+// which may be prefixed with "v". ErrReleaseNotFound is returned if the
+// release is unknown
+func GetRelease(version string) (*Release, error) {
+	releases, err := GetLatestReleases(releaseMetadataCount)
 	if err != nil {
-		return []*Release{}, ErrGetLatestReleases(err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return []*Release{}, ErrGetLatestReleases(fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	version = strings.TrimPrefix(version, "v")
+	for _, release := range releases {
+		if release != nil && strings.TrimPrefix(release.TagName, "v") == version {
+			return release, nil
+		}
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []*Release{}, ErrGetLatestReleases(err)
+	return nil, ErrReleaseNotFound(version)
+}
+
+// fetchReleaseMetadata fetches the raw release metadata from the release mirror,
+// or from the github API when no mirror is configured
+func fetchReleaseMetadata() ([]byte, error) {
+	if os.Getenv(ReleaseMirrorEnv) == "" {
+		return fetchGithubReleases()
 	}
 
-	var releaseList []*Release
+	reader, err := openSource(context.Background(), fmt.Sprintf("%s/%s", ReleaseMirror(), ReleaseMetadataFile))
+	if err != nil {
+		return nil, err
+	}
 
-	if err = json.Unmarshal(body, &releaseList); err != nil {
-		return []*Release{}, ErrGetLatestReleases(err)
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
 	}
 
-	if err = resp.Body.Close(); err != nil {
-		return []*Release{}, ErrGetLatestReleases(err)
+	if err = reader.Close(); err != nil {
+		return nil, err
 	}
 
-	return releaseList, nil
+	return body, nil
 }
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+)
+
+const (
+	// ReleasePrereleasesEnv is the environment variable which, set to true,
+	// includes the alpha, beta and rc builds in the advertised releases
+	ReleasePrereleasesEnv = "ISTIO_RELEASE_PRERELEASES"
+
+	// ReleaseLatestPatchesEnv is the environment variable which, set to true,
+	// advertises only the latest patch release of each minor version
+	ReleaseLatestPatchesEnv = "ISTIO_RELEASE_LATEST_PATCHES"
+)
+
+// releaseVersionPattern matches versions like 1.8.1, v1.9.0-rc.1 or 1.10
+var releaseVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.\-]+))?$`)
+
+// ReleaseVersion is a parsed istio release version
+type ReleaseVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+
+	// Original is the version as named by the release
+	Original string
+}
+
+// ReleaseFilter selects the releases advertised by the adapter
+type ReleaseFilter struct {
+	// Prereleases includes the alpha, beta and rc builds
+	Prereleases bool
+	// LatestPatches keeps only the latest patch release of each minor version
+	LatestPatches bool
+}
+
+// ReleaseFilterFromEnv reads the release filter from the environment
+func ReleaseFilterFromEnv() ReleaseFilter {
+	enabled := func(key string) bool {
+		v, err := strconv.ParseBool(os.Getenv(key))
+		return err == nil && v
+	}
+
+	return ReleaseFilter{
+		Prereleases:   enabled(ReleasePrereleasesEnv),
+		LatestPatches: enabled(ReleaseLatestPatchesEnv),
+	}
+}
+
+// ParseReleaseVersion parses a release version, a leading "v" is accepted
+// and a missing patch number is read as zero
+func ParseReleaseVersion(version string) (ReleaseVersion, error) {
+	version = strings.TrimSpace(version)
+
+	m := releaseVersionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return ReleaseVersion{}, fmt.Errorf("invalid istio version %q", version)
+	}
+
+	v := ReleaseVersion{Prerelease: m[4], Original: version}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+
+	return v, nil
+}
+
+// releaseVersionOf returns the version of a release, read from its tag or,
+// failing that, from the last word of its name like "Istio 1.8.1"
+func releaseVersionOf(release *Release) (ReleaseVersion, bool) {
+	if v, err := ParseReleaseVersion(release.TagName); err == nil {
+		return v, true
+	}
+
+	fields := strings.Fields(string(release.Name))
+	if len(fields) == 0 {
+		return ReleaseVersion{}, false
+	}
+
+	v, err := ParseReleaseVersion(fields[len(fields)-1])
+	return v, err == nil
+}
+
+// IsPrerelease reports whether the version is an alpha, beta or rc build
+func (v ReleaseVersion) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// Less orders versions by semantic version precedence
+func (v ReleaseVersion) Less(o ReleaseVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	if v.Patch != o.Patch {
+		return v.Patch < o.Patch
+	}
+
+	return comparePrerelease(v.Prerelease, o.Prerelease) < 0
+}
+
+// comparePrerelease compares the pre-release parts of two versions, a
+// release without pre-release part has precedence over one with it
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				return compareInts(an, bn)
+			}
+		case aerr == nil:
+			// Numeric identifiers have lower precedence
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareInts(len(as), len(bs))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// selectReleaseVersions returns the versions of the releases passing the
+// filter, newest first and limited to limit entries. Drafts, duplicates and
+// releases without a recognizable version are skipped
+func selectReleaseVersions(releases []*Release, filter ReleaseFilter, limit int) []adapter.Version {
+	seen := map[ReleaseVersion]bool{}
+	versions := []ReleaseVersion{}
+
+	for _, release := range releases {
+		if release == nil || release.Draft {
+			continue
+		}
+
+		v, ok := releaseVersionOf(release)
+		if !ok || (v.IsPrerelease() && !filter.Prereleases) {
+			continue
+		}
+
+		key := v
+		key.Original = ""
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		versions = append(versions, v)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[j].Less(versions[i])
+	})
+
+	result := []adapter.Version{}
+	minors := map[[2]int]bool{}
+	for _, v := range versions {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+
+		minor := [2]int{v.Major, v.Minor}
+		if filter.LatestPatches && minors[minor] {
+			continue
+		}
+		minors[minor] = true
+
+		result = append(result, adapter.Version(strings.TrimPrefix(v.Original, "v")))
+	}
+
+	return result
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+)
+
+func TestParseReleaseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    ReleaseVersion
+		wantErr bool
+	}{
+		{version: "1.8.1", want: ReleaseVersion{Major: 1, Minor: 8, Patch: 1, Original: "1.8.1"}},
+		{version: "v1.9.0-rc.1", want: ReleaseVersion{Major: 1, Minor: 9, Prerelease: "rc.1", Original: "v1.9.0-rc.1"}},
+		{version: "1.10", want: ReleaseVersion{Major: 1, Minor: 10, Original: "1.10"}},
+		{version: "Istio 1.8.1", wantErr: true},
+		{version: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReleaseVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReleaseVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReleaseVersion(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseVersion_Less(t *testing.T) {
+	ordered := []string{"1.8.0", "1.9.0-alpha.1", "1.9.0-alpha.beta", "1.9.0-beta.2", "1.9.0-beta.11", "1.9.0-rc.1", "1.9.0", "1.9.1", "1.10.0"}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := ParseReleaseVersion(ordered[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseReleaseVersion(ordered[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !a.Less(b) || b.Less(a) {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+}
+
+func Test_selectReleaseVersions(t *testing.T) {
+	releases := []*Release{
+		{TagName: "1.9.0-rc.1", Name: "Istio 1.9.0-rc.1"},
+		{TagName: "1.8.2", Name: "Istio 1.8.2"},
+		{TagName: "1.10.0", Name: "Istio 1.10.0"},
+		{TagName: "1.7.6", Name: "Istio 1.7.6"},
+		{TagName: "", Name: "Istio 1.8.1"},
+		{TagName: "1.8.1", Name: "1.8.1"},
+		{TagName: "1.9.1", Name: "Istio 1.9.1", Draft: true},
+		{TagName: "nightly", Name: "IstioNightly"},
+		{TagName: "", Name: ""},
+		nil,
+	}
+
+	tests := []struct {
+		name   string
+		filter ReleaseFilter
+		limit  int
+		want   []adapter.Version
+	}{
+		{
+			name:   "stable releases",
+			filter: ReleaseFilter{},
+			limit:  3,
+			want:   []adapter.Version{"1.10.0", "1.8.2", "1.8.1"},
+		},
+		{
+			name:   "pre-releases",
+			filter: ReleaseFilter{Prereleases: true},
+			limit:  3,
+			want:   []adapter.Version{"1.10.0", "1.9.0-rc.1", "1.8.2"},
+		},
+		{
+			name:   "latest patches",
+			filter: ReleaseFilter{LatestPatches: true},
+			limit:  3,
+			want:   []adapter.Version{"1.10.0", "1.8.2", "1.7.6"},
+		},
+		{
+			name:   "limit beyond the releases",
+			filter: ReleaseFilter{},
+			limit:  10,
+			want:   []adapter.Version{"1.10.0", "1.8.2", "1.8.1", "1.7.6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectReleaseVersions(releases, tt.filter, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectReleaseVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Exposure modes of the addons
+const (
+	exposeClusterIP    = "ClusterIP"
+	exposeNodePort     = "NodePort"
+	exposeLoadBalancer = "LoadBalancer"
+	exposeGateway      = "Gateway"
+)
+
+// The default node port range of the kubernetes API server, ports outside
+// of it are rejected unless the cluster is configured otherwise
+const (
+	minNodePort = 30000
+	maxNodePort = 32767
+)
+
+// ingressGatewaySelector selects the ingress gateway the addon routes are bound to
+const ingressGatewaySelector = "istio=ingressgateway"
+
+var (
+	// exposureModes are the ways an addon can be made reachable
+	exposureModes = []string{exposeClusterIP, exposeNodePort, exposeLoadBalancer, exposeGateway}
+
+	// hostPattern matches the hosts an addon can be routed from
+	hostPattern = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9\-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9\-]*[a-z0-9])?)*$`)
+)
+
+// validateExposure normalizes the exposure mode of the options and checks
+// that the node port and the host are only given for the modes using them
+func validateExposure(opts *addonOptions) error {
+	mode := ""
+	for _, m := range exposureModes {
+		if strings.EqualFold(m, opts.Expose) {
+			mode = m
+		}
+	}
+	if mode == "" {
+		return fmt.Errorf("unknown exposure mode %q, supported modes are: %s", opts.Expose, strings.Join(exposureModes, ", "))
+	}
+	opts.Expose = mode
+
+	if opts.NodePort != 0 {
+		if mode != exposeNodePort {
+			return fmt.Errorf("a node port can only be chosen for the %s exposure mode", exposeNodePort)
+		}
+		if opts.NodePort < minNodePort || opts.NodePort > maxNodePort {
+			return fmt.Errorf("invalid node port %d, node ports must be in the range %d-%d", opts.NodePort, minNodePort, maxNodePort)
+		}
+	}
+
+	switch {
+	case mode == exposeGateway && opts.Host == "":
+		return fmt.Errorf("a host is required for the %s exposure mode", exposeGateway)
+	case mode != exposeGateway && opts.Host != "":
+		return fmt.Errorf("a host can only be given for the %s exposure mode", exposeGateway)
+	case opts.Host != "" && !hostPattern.MatchString(opts.Host):
+		return fmt.Errorf("invalid host %q", opts.Host)
+	}
+
+	return nil
+}
+
+// exposeAddon makes the service of the addon reachable as configured by the
+// options, and returns the endpoint the addon can be reached at
+func (istio *Istio) exposeAddon(ctx context.Context, namespace, service string, opts addonOptions) (string, error) {
+	if opts.Expose == exposeGateway {
+		if err := istio.checkIngressGateway(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	services := istio.KubeClient.CoreV1().Services(namespace)
+
+	svc, err := services.Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	exposeService(svc, opts)
+	if svc, err = services.Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+
+	if opts.Expose == exposeGateway {
+		route, err := addonRouteManifest(svc, opts.Host)
+		if err != nil {
+			return "", err
+		}
+		if err := istio.applyManifest(route, false, namespace); err != nil {
+			return "", err
+		}
+	}
+
+	nodeAddress := ""
+	if opts.Expose == exposeNodePort {
+		nodeAddress = istio.nodeAddress(ctx)
+	}
+
+	return addonEndpoint(svc, opts, nodeAddress), nil
+}
+
+// checkIngressGateway checks that an ingress gateway the addon routes can be
+// bound to is installed, in any namespace
+func (istio *Istio) checkIngressGateway(ctx context.Context) error {
+	gateways, err := istio.KubeClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: ingressGatewaySelector})
+	if err != nil {
+		return err
+	}
+	if len(gateways.Items) == 0 {
+		return fmt.Errorf("no ingress gateway labelled %s is installed, the addon can't be exposed through the gateway", ingressGatewaySelector)
+	}
+
+	return nil
+}
+
+// unexposeAddon removes the ingress gateway route of the addon, which only
+// exists if the addon was exposed through the gateway
+func (istio *Istio) unexposeAddon(ctx context.Context, opID, namespace, service string) error {
+	route, err := addonRouteManifest(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: service, Namespace: namespace}}, "*")
+	if err != nil {
+		return err
+	}
+
+	return istio.reportResults(opID, istio.applyResources(ctx, string(route), true, namespace, addonIgnoreRules))
+}
+
+// exposeService switches the service to the type of the exposure mode, the
+// addons exposed through the ingress gateway keep a cluster internal service
+func exposeService(svc *corev1.Service, opts addonOptions) {
+	switch opts.Expose {
+	case exposeNodePort:
+		svc.Spec.Type = corev1.ServiceTypeNodePort
+	case exposeLoadBalancer:
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+	default:
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		// Node ports and the external traffic policy are rejected for these services
+		for i := range svc.Spec.Ports {
+			svc.Spec.Ports[i].NodePort = 0
+		}
+		svc.Spec.ExternalTrafficPolicy = ""
+		svc.Spec.HealthCheckNodePort = 0
+	}
+
+	if opts.NodePort != 0 && len(svc.Spec.Ports) > 0 {
+		svc.Spec.Ports[0].NodePort = opts.NodePort
+	}
+}
+
+// addonRouteManifest returns the Gateway and the VirtualService routing the
+// host through the ingress gateway to the first port of the service
+func addonRouteManifest(svc *corev1.Service, host string) ([]byte, error) {
+	var port int32
+	if len(svc.Spec.Ports) > 0 {
+		port = svc.Spec.Ports[0].Port
+	}
+
+	gateway := map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "Gateway",
+		"metadata": map[string]interface{}{
+			"name": svc.Name + "-gateway",
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]string{
+				"istio": "ingressgateway",
+			},
+			"servers": []map[string]interface{}{
+				{
+					"port": map[string]interface{}{
+						"number":   80,
+						"name":     "http-" + svc.Name,
+						"protocol": "HTTP",
+					},
+					"hosts": []string{host},
+				},
+			},
+		},
+	}
+
+	virtualSvc := map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name": svc.Name,
+		},
+		"spec": map[string]interface{}{
+			"hosts":    []string{host},
+			"gateways": []string{svc.Name + "-gateway"},
+			"http": []map[string]interface{}{
+				{
+					"route": []map[string]interface{}{
+						{
+							"destination": map[string]interface{}{
+								"host": svc.Name,
+								"port": map[string]interface{}{
+									"number": port,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	manifests := make([]string, 0, 2)
+	for _, object := range []interface{}{gateway, virtualSvc} {
+		out, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, string(out))
+	}
+
+	return []byte(strings.Join(manifests, "---\n")), nil
+}
+
+// addonEndpoint returns the endpoint the service of the addon is reachable at
+func addonEndpoint(svc *corev1.Service, opts addonOptions, nodeAddress string) string {
+	var port corev1.ServicePort
+	if len(svc.Spec.Ports) > 0 {
+		port = svc.Spec.Ports[0]
+	}
+
+	switch opts.Expose {
+	case exposeGateway:
+		return fmt.Sprintf("http://%s through the istio ingress gateway", opts.Host)
+	case exposeNodePort:
+		if nodeAddress == "" {
+			nodeAddress = "<node address>"
+		}
+		return fmt.Sprintf("%s:%d", nodeAddress, port.NodePort)
+	case exposeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if address := ingress.IP; address != "" {
+				return fmt.Sprintf("%s:%d", address, port.Port)
+			}
+			if address := ingress.Hostname; address != "" {
+				return fmt.Sprintf("%s:%d", address, port.Port)
+			}
+		}
+		return fmt.Sprintf("external address pending, port %d", port.Port)
+	}
+
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, port.Port)
+}
+
+// nodeAddress returns an address the nodes of the cluster can be reached at,
+// external addresses are preferred over internal ones
+func (istio *Istio) nodeAddress(ctx context.Context) string {
+	nodes, err := istio.KubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return ""
+	}
+
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, node := range nodes.Items {
+			for _, address := range node.Status.Addresses {
+				if address.Type == addressType && address.Address != "" {
+					return address.Address
+				}
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,186 @@
+package istio
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func addonService(typ corev1.ServiceType, nodePort int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafana", Namespace: "istio-system"},
+		Spec: corev1.ServiceSpec{
+			Type: typ,
+			Ports: []corev1.ServicePort{
+				{Name: "service", Port: 3000, NodePort: nodePort},
+			},
+		},
+	}
+}
+
+func Test_exposeService(t *testing.T) {
+	tests := []struct {
+		name         string
+		svc          *corev1.Service
+		opts         addonOptions
+		wantType     corev1.ServiceType
+		wantNodePort int32
+	}{
+		{
+			name:     "load balancer",
+			svc:      addonService(corev1.ServiceTypeClusterIP, 0),
+			opts:     addonOptions{Expose: exposeLoadBalancer},
+			wantType: corev1.ServiceTypeLoadBalancer,
+		},
+		{
+			name:         "chosen node port",
+			svc:          addonService(corev1.ServiceTypeClusterIP, 0),
+			opts:         addonOptions{Expose: exposeNodePort, NodePort: 30300},
+			wantType:     corev1.ServiceTypeNodePort,
+			wantNodePort: 30300,
+		},
+		{
+			name:         "node port kept",
+			svc:          addonService(corev1.ServiceTypeLoadBalancer, 31000),
+			opts:         addonOptions{Expose: exposeNodePort},
+			wantType:     corev1.ServiceTypeNodePort,
+			wantNodePort: 31000,
+		},
+		{
+			name:     "back to cluster ip",
+			svc:      addonService(corev1.ServiceTypeLoadBalancer, 31000),
+			opts:     addonOptions{Expose: exposeClusterIP},
+			wantType: corev1.ServiceTypeClusterIP,
+		},
+		{
+			name:     "gateway keeps a cluster ip",
+			svc:      addonService(corev1.ServiceTypeNodePort, 31000),
+			opts:     addonOptions{Expose: exposeGateway, Host: "grafana.example.com"},
+			wantType: corev1.ServiceTypeClusterIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exposeService(tt.svc, tt.opts)
+			if tt.svc.Spec.Type != tt.wantType {
+				t.Errorf("exposeService() type = %v, want %v", tt.svc.Spec.Type, tt.wantType)
+			}
+			if got := tt.svc.Spec.Ports[0].NodePort; got != tt.wantNodePort {
+				t.Errorf("exposeService() node port = %v, want %v", got, tt.wantNodePort)
+			}
+		})
+	}
+}
+
+func Test_addonEndpoint(t *testing.T) {
+	balanced := addonService(corev1.ServiceTypeLoadBalancer, 31000)
+	balanced.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+
+	tests := []struct {
+		name        string
+		svc         *corev1.Service
+		opts        addonOptions
+		nodeAddress string
+		want        string
+	}{
+		{
+			name: "cluster ip",
+			svc:  addonService(corev1.ServiceTypeClusterIP, 0),
+			opts: addonOptions{Expose: exposeClusterIP},
+			want: "grafana.istio-system.svc.cluster.local:3000",
+		},
+		{
+			name:        "node port",
+			svc:         addonService(corev1.ServiceTypeNodePort, 30300),
+			opts:        addonOptions{Expose: exposeNodePort},
+			nodeAddress: "10.0.0.4",
+			want:        "10.0.0.4:30300",
+		},
+		{
+			name: "node port without node address",
+			svc:  addonService(corev1.ServiceTypeNodePort, 30300),
+			opts: addonOptions{Expose: exposeNodePort},
+			want: "<node address>:30300",
+		},
+		{
+			name: "load balancer",
+			svc:  balanced,
+			opts: addonOptions{Expose: exposeLoadBalancer},
+			want: "lb.example.com:3000",
+		},
+		{
+			name: "pending load balancer",
+			svc:  addonService(corev1.ServiceTypeLoadBalancer, 31000),
+			opts: addonOptions{Expose: exposeLoadBalancer},
+			want: "external address pending, port 3000",
+		},
+		{
+			name: "gateway",
+			svc:  addonService(corev1.ServiceTypeClusterIP, 0),
+			opts: addonOptions{Expose: exposeGateway, Host: "grafana.example.com"},
+			want: "http://grafana.example.com through the istio ingress gateway",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addonEndpoint(tt.svc, tt.opts, tt.nodeAddress); got != tt.want {
+				t.Errorf("addonEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_addonRouteManifest(t *testing.T) {
+	manifest, err := addonRouteManifest(addonService(corev1.ServiceTypeClusterIP, 0), "grafana.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := strings.Split(string(manifest), "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("addonRouteManifest() returned %d documents, want 2", len(docs))
+	}
+
+	var gateway, virtualSvc struct {
+		Kind     string
+		Metadata struct{ Name string }
+		Spec     struct {
+			Hosts    []string
+			Gateways []string
+			HTTP     []struct {
+				Route []struct {
+					Destination struct {
+						Host string
+						Port struct{ Number int32 }
+					}
+				}
+			} `yaml:"http"`
+		}
+	}
+	if err := yaml.Unmarshal([]byte(docs[0]), &gateway); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(docs[1]), &virtualSvc); err != nil {
+		t.Fatal(err)
+	}
+
+	if gateway.Kind != "Gateway" || gateway.Metadata.Name != "grafana-gateway" {
+		t.Errorf("unexpected gateway %s %s", gateway.Kind, gateway.Metadata.Name)
+	}
+	if virtualSvc.Kind != "VirtualService" || len(virtualSvc.Spec.Gateways) != 1 || virtualSvc.Spec.Gateways[0] != "grafana-gateway" {
+		t.Errorf("virtual service not bound to the gateway: %+v", virtualSvc)
+	}
+	if len(virtualSvc.Spec.Hosts) != 1 || virtualSvc.Spec.Hosts[0] != "grafana.example.com" {
+		t.Errorf("virtual service hosts = %v", virtualSvc.Spec.Hosts)
+	}
+	if len(virtualSvc.Spec.HTTP) != 1 || len(virtualSvc.Spec.HTTP[0].Route) != 1 {
+		t.Fatalf("virtual service routes = %+v", virtualSvc.Spec.HTTP)
+	}
+	if dest := virtualSvc.Spec.HTTP[0].Route[0].Destination; dest.Host != "grafana" || dest.Port.Number != 3000 {
+		t.Errorf("virtual service destination = %+v", dest)
+	}
+}
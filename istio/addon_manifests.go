@@ -0,0 +1,115 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-adapter-library/status"
+	"github.com/layer5io/meshery-istio/internal/config"
+	"github.com/layer5io/meshkit/utils"
+)
+
+// addonManifestURL is the location of the addon manifests of an istio
+// release, formatted with the release tag and the path of the manifest
+var addonManifestURL = "https://raw.githubusercontent.com/istio/istio/%s/%s"
+
+// minAddonRelease is the first release shipping the addon manifests as samples
+var minAddonRelease = config.ReleaseVersion{Major: 1, Minor: 7}
+
+// addonReleaseTag returns the tag of the istio release the addon manifests
+// of the given version are taken from. Image variants like "1.8.1-distroless"
+// are mapped to their release, releases without addon samples are rejected
+func addonReleaseTag(version string) (string, bool) {
+	version = strings.TrimSuffix(strings.TrimSpace(version), "-distroless")
+
+	v, err := config.ParseReleaseVersion(version)
+	if err != nil || v.Less(minAddonRelease) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(v.Original, "v"), true
+}
+
+// addonVersion returns the istio release the addon manifests are taken from,
+// either the pinned one or the one of the installed control plane. An empty
+// version is returned when there is no control plane to match
+func (istio *Istio) addonVersion(ctx context.Context, opts addonOptions) string {
+	if opts.Version != "" {
+		return opts.Version
+	}
+
+	if istio.KubeClient == nil {
+		return ""
+	}
+
+	planes, err := istio.discoverControlPlanes(ctx)
+	if err != nil {
+		istio.Log.Warn(ErrMeshConfig(err))
+		return ""
+	}
+
+	if version := meshSpecFor(planes)["version"]; version != status.None {
+		return version
+	}
+
+	return ""
+}
+
+// addonManifests returns the manifests of the addon operation matching the
+// istio release. The bundled manifests of the operation templates are used
+// when the release is unknown or its manifests can't be fetched, the reason
+// is reported as a warning
+func (istio *Istio) addonManifests(opID string, op *adapter.Operation, version string) ([]string, error) {
+	if source := addonManifestSource(op, version); source != "" {
+		manifest, err := utils.ReadFileSource(source)
+		if err == nil {
+			return []string{manifest}, nil
+		}
+		istio.warnAddonFallback(opID, fmt.Sprintf("Unable to fetch the addon manifest of istio %s, using the bundled one", version), err)
+	} else if version != "" {
+		istio.warnAddonFallback(opID, "Using the bundled addon manifest", fmt.Errorf("istio %s doesn't ship addon manifests", version))
+	}
+
+	manifests := make([]string, 0, len(op.Templates))
+	for _, template := range op.Templates {
+		manifest, err := utils.ReadFileSource(string(template))
+		if err != nil {
+			return nil, ErrAddonFromTemplate(err)
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests, nil
+}
+
+// addonManifestSource returns the location of the addon manifest of the
+// operation in the given istio release, empty if there is none
+func addonManifestSource(op *adapter.Operation, version string) string {
+	manifest := op.AdditionalProperties[config.AddonManifest]
+	if manifest == "" {
+		return ""
+	}
+
+	tag, ok := addonReleaseTag(version)
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf(addonManifestURL, tag, manifest)
+}
+
+// warnAddonFallback reports that the bundled addon manifests are used
+func (istio *Istio) warnAddonFallback(opID, summary string, err error) {
+	if opID == "" {
+		istio.Log.Warn(ErrAddonFromTemplate(err))
+		return
+	}
+
+	istio.StreamWarn(&adapter.Event{
+		Operationid: opID,
+		Summary:     summary,
+		Details:     err.Error(),
+	}, ErrAddonFromTemplate(err))
+}
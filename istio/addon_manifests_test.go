@@ -0,0 +1,71 @@
+package istio
+
+import (
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-istio/internal/config"
+)
+
+func Test_addonManifestSource(t *testing.T) {
+	op := &adapter.Operation{
+		Templates: []adapter.Template{"file://templates/addons/kiali.yaml"},
+		AdditionalProperties: map[string]string{
+			config.AddonManifest: "samples/addons/kiali.yaml",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		op      *adapter.Operation
+		version string
+		want    string
+	}{
+		{
+			name:    "release tag",
+			op:      op,
+			version: "1.8.1",
+			want:    "https://raw.githubusercontent.com/istio/istio/1.8.1/samples/addons/kiali.yaml",
+		},
+		{
+			name:    "prefixed version",
+			op:      op,
+			version: "v1.9.0",
+			want:    "https://raw.githubusercontent.com/istio/istio/1.9.0/samples/addons/kiali.yaml",
+		},
+		{
+			name:    "distroless image",
+			op:      op,
+			version: "1.8.1-distroless",
+			want:    "https://raw.githubusercontent.com/istio/istio/1.8.1/samples/addons/kiali.yaml",
+		},
+		{
+			name:    "pre-release",
+			op:      op,
+			version: "1.9.0-rc.1",
+			want:    "https://raw.githubusercontent.com/istio/istio/1.9.0-rc.1/samples/addons/kiali.yaml",
+		},
+		{
+			name:    "unknown release",
+			op:      op,
+			version: "",
+		},
+		{
+			name:    "release without addon manifests",
+			op:      op,
+			version: "1.6.8",
+		},
+		{
+			name:    "operation without addon manifest",
+			op:      &adapter.Operation{},
+			version: "1.8.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addonManifestSource(tt.op, tt.version); got != tt.want {
+				t.Errorf("addonManifestSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
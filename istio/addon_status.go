@@ -0,0 +1,255 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-istio/internal/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// addonOperations are the operations installing the addons, in the order
+// their status is reported
+var addonOperations = []string{
+	config.PrometheusAddon,
+	config.GrafanaAddon,
+	config.KialiAddon,
+	config.JaegerAddon,
+	config.ZipkinAddon,
+}
+
+var virtualServiceGVR = schema.GroupVersionResource{
+	Group:    "networking.istio.io",
+	Version:  "v1beta1",
+	Resource: "virtualservices",
+}
+
+// addonHealth is the state of an addon in the cluster
+type addonHealth struct {
+	Name       string
+	Installed  bool
+	Ready      bool
+	Components []componentStatus
+	Endpoint   string
+}
+
+// addonsStatus returns the health of every addon the operations can install,
+// looked up next to the control plane
+func (istio *Istio) addonsStatus(ctx context.Context, operations adapter.Operations) ([]addonHealth, error) {
+	if istio.KubeClient == nil {
+		return nil, ErrNilClient
+	}
+
+	namespace := istio.findControlPlaneNamespace(ctx, "")
+
+	addons := make([]addonHealth, 0, len(addonOperations))
+	for _, name := range addonOperations {
+		op, ok := operations[name]
+		if !ok || op == nil {
+			continue
+		}
+
+		health, err := istio.addonHealth(ctx, namespace, op.AdditionalProperties[config.ServiceName])
+		if err != nil {
+			return nil, ErrAddonNotReady(err)
+		}
+		health.Name = name
+		addons = append(addons, health)
+	}
+
+	return addons, nil
+}
+
+// waitForAddon waits for the deployments behind the service of the addon to
+// become ready, and returns the health of the addon along with its endpoint
+func (istio *Istio) waitForAddon(ctx context.Context, opID, namespace, service string, timeout time.Duration) (addonHealth, error) {
+	istio.streamProgress(opID, fmt.Sprintf("Waiting for %s", service), fmt.Sprintf("Waiting up to %s for the deployments of %s in %s to become ready", timeout, service, namespace))
+
+	reported := map[string]bool{}
+	var health addonHealth
+
+	err := wait.PollImmediate(readinessPollInterval, timeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		current, err := istio.addonHealth(ctx, namespace, service)
+		if err != nil {
+			istio.Log.Warn(ErrAddonNotReady(err))
+			return false, nil
+		}
+		health = current
+
+		for _, component := range health.Components {
+			if component.Ready && !reported[component.Name] {
+				reported[component.Name] = true
+				istio.streamProgress(opID, fmt.Sprintf("%s is ready", component.Name), component.Message)
+			}
+		}
+
+		return health.Ready, nil
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return health, ErrAddonNotReady(fmt.Errorf("%s\n%s", err.Error(), formatComponentStatus(istio.diagnose(ctx, namespace, health.Components))))
+	}
+
+	return health, nil
+}
+
+// addonHealth returns the readiness of the deployments selected by the
+// service of the addon and the endpoint the addon is reachable at
+func (istio *Istio) addonHealth(ctx context.Context, namespace, service string) (addonHealth, error) {
+	health := addonHealth{Name: service}
+
+	svc, err := istio.KubeClient.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if kubeerror.IsNotFound(err) {
+		return health, nil
+	}
+	if err != nil {
+		return health, err
+	}
+	health.Installed = true
+
+	deploys, err := istio.KubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return health, err
+	}
+
+	health.Components = deploymentComponents("", addonDeployments(svc, deploys.Items))
+	if len(health.Components) == 0 {
+		health.Components = []componentStatus{{Name: service, Message: "no deployment backs the service"}}
+	}
+
+	health.Ready = true
+	for _, component := range health.Components {
+		health.Ready = health.Ready && component.Ready
+	}
+
+	health.Endpoint = istio.discoverAddonEndpoint(ctx, svc)
+
+	return health, nil
+}
+
+// discoverAddonEndpoint returns the endpoint of the addon, as exposed by the
+// type of its service or by a route through the ingress gateway
+func (istio *Istio) discoverAddonEndpoint(ctx context.Context, svc *corev1.Service) string {
+	opts := addonOptions{Expose: exposureOf(svc)}
+
+	nodeAddress := ""
+	switch opts.Expose {
+	case exposeNodePort:
+		nodeAddress = istio.nodeAddress(ctx)
+	case exposeClusterIP:
+		if host := istio.addonRouteHost(ctx, svc); host != "" {
+			opts.Expose, opts.Host = exposeGateway, host
+		}
+	}
+
+	return addonEndpoint(svc, opts, nodeAddress)
+}
+
+// addonRouteHost returns the host of the ingress gateway route of the
+// addon, empty if the addon isn't routed through the gateway
+func (istio *Istio) addonRouteHost(ctx context.Context, svc *corev1.Service) string {
+	client, err := dynamic.NewForConfig(&istio.RestConfig)
+	if err != nil {
+		return ""
+	}
+
+	route, err := client.Resource(virtualServiceGVR).Namespace(svc.Namespace).Get(ctx, svc.Name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+
+	hosts, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hosts")
+	if len(hosts) == 0 {
+		return ""
+	}
+
+	return hosts[0]
+}
+
+// exposureOf returns the exposure mode matching the type of the service
+func exposureOf(svc *corev1.Service) string {
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeNodePort:
+		return exposeNodePort
+	case corev1.ServiceTypeLoadBalancer:
+		return exposeLoadBalancer
+	}
+
+	return exposeClusterIP
+}
+
+// addonDeployments returns the deployments whose pods the service selects
+func addonDeployments(svc *corev1.Service, deploys []appsv1.Deployment) []appsv1.Deployment {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	var selected []appsv1.Deployment
+	for _, deploy := range deploys {
+		if selector.Matches(labels.Set(deploy.Spec.Template.Labels)) {
+			selected = append(selected, deploy)
+		}
+	}
+
+	return selected
+}
+
+// formatAddonHealth renders the health of the addons for an event
+func formatAddonHealth(addons []addonHealth) string {
+	lines := make([]string, 0, len(addons))
+	for _, addon := range addons {
+		if !addon.Installed {
+			lines = append(lines, fmt.Sprintf("%s: not installed", addon.Name))
+			continue
+		}
+
+		state := "not ready"
+		if addon.Ready {
+			state = "ready"
+		}
+
+		components := make([]string, 0, len(addon.Components))
+		for _, component := range addon.Components {
+			components = append(components, fmt.Sprintf("%s %s", component.Name, component.Message))
+		}
+		sort.Strings(components)
+
+		lines = append(lines, fmt.Sprintf("%s: %s at %s (%s)", addon.Name, state, addon.Endpoint, strings.Join(components, ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// healthyAddons counts the installed and the ready addons
+func healthyAddons(addons []addonHealth) (installed, ready int) {
+	for _, addon := range addons {
+		if addon.Installed {
+			installed++
+		}
+		if addon.Ready {
+			ready++
+		}
+	}
+
+	return installed, ready
+}
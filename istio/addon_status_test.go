@@ -0,0 +1,109 @@
+package istio
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func deploymentWithLabels(name string, labels map[string]string) appsv1.Deployment {
+	deploy := appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	deploy.Spec.Template.Labels = labels
+
+	return deploy
+}
+
+func Test_addonDeployments(t *testing.T) {
+	deploys := []appsv1.Deployment{
+		deploymentWithLabels("jaeger", map[string]string{"app": "jaeger", "version": "v1"}),
+		deploymentWithLabels("grafana", map[string]string{"app": "grafana"}),
+		deploymentWithLabels("istiod", map[string]string{"app": "istiod"}),
+	}
+
+	tests := []struct {
+		name     string
+		selector map[string]string
+		want     []string
+	}{
+		{
+			name:     "selected deployment",
+			selector: map[string]string{"app": "jaeger"},
+			want:     []string{"jaeger"},
+		},
+		{
+			name:     "no matching deployment",
+			selector: map[string]string{"app": "kiali"},
+		},
+		{
+			name: "service without selector",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &corev1.Service{Spec: corev1.ServiceSpec{Selector: tt.selector}}
+
+			got := addonDeployments(svc, deploys)
+			if len(got) != len(tt.want) {
+				t.Fatalf("addonDeployments() returned %d deployments, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Name != tt.want[i] {
+					t.Errorf("addonDeployments()[%d] = %s, want %s", i, got[i].Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_exposureOf(t *testing.T) {
+	tests := []struct {
+		typ  corev1.ServiceType
+		want string
+	}{
+		{typ: corev1.ServiceTypeClusterIP, want: exposeClusterIP},
+		{typ: corev1.ServiceTypeNodePort, want: exposeNodePort},
+		{typ: corev1.ServiceTypeLoadBalancer, want: exposeLoadBalancer},
+		{typ: "", want: exposeClusterIP},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			if got := exposureOf(addonService(tt.typ, 0)); got != tt.want {
+				t.Errorf("exposureOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatAddonHealth(t *testing.T) {
+	addons := []addonHealth{
+		{
+			Name:       "grafana-addon",
+			Installed:  true,
+			Ready:      true,
+			Components: []componentStatus{{Name: "grafana", Ready: true, Message: "1/1 replicas available"}},
+			Endpoint:   "10.0.0.4:30300",
+		},
+		{
+			Name:       "kiali-addon",
+			Installed:  true,
+			Components: []componentStatus{{Name: "kiali", Message: "0/1 replicas available"}},
+			Endpoint:   "kiali.istio-system.svc.cluster.local:20001",
+		},
+		{
+			Name: "zipkin-addon",
+		},
+	}
+
+	want := "grafana-addon: ready at 10.0.0.4:30300 (grafana 1/1 replicas available)\n" +
+		"kiali-addon: not ready at kiali.istio-system.svc.cluster.local:20001 (kiali 0/1 replicas available)\n" +
+		"zipkin-addon: not installed"
+	if got := formatAddonHealth(addons); got != want {
+		t.Errorf("formatAddonHealth() = %q, want %q", got, want)
+	}
+
+	if installed, ready := healthyAddons(addons); installed != 2 || ready != 1 {
+		t.Errorf("healthyAddons() = %d, %d, want 2, 1", installed, ready)
+	}
+}
@@ -0,0 +1,199 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/status"
+	"gopkg.in/yaml.v2"
+
+	corev1 "k8s.io/api/core/v1"
+	kubeerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// uninstallAddon removes the objects of the addon manifests along with the
+// ingress gateway route of its service and, when requested, its CRDs. The
+// removal is verified unless the readiness timeout of the options is zero
+func (istio *Istio) uninstallAddon(ctx context.Context, opID, namespace, service string, manifests []string, opts addonOptions) (string, error) {
+	if istio.KubeClient == nil {
+		if len(manifests) > 0 {
+			return status.Removing, ErrNilClient
+		}
+		// Nothing was installed through the manifests, there is nothing to remove
+		return status.Removed, nil
+	}
+
+	var objects []manifestObject
+	if service != "" {
+		route, err := addonRouteManifest(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: service, Namespace: namespace}}, "*")
+		if err != nil {
+			return status.Removing, ErrAddonFromTemplate(err)
+		}
+		objects = append(objects, manifestObjects(string(route))...)
+
+		if err := istio.unexposeAddon(ctx, opID, namespace, service); err != nil {
+			return status.Removing, ErrAddonFromTemplate(err)
+		}
+	}
+
+	for _, manifest := range manifests {
+		// The CRDs shipped with the manifests are shared with other workloads
+		if !opts.RemoveCRDs {
+			manifest = withoutKinds(manifest, "CustomResourceDefinition")
+		}
+		objects = append(objects, manifestObjects(manifest)...)
+
+		if err := istio.reportResults(opID, istio.applyResources(ctx, manifest, true, namespace, addonIgnoreRules)); err != nil {
+			return status.Removing, ErrAddonFromTemplate(err)
+		}
+	}
+
+	if opts.RemoveCRDs {
+		if crds := addonCRDManifest(opts.CRDs); crds != "" {
+			objects = append(objects, manifestObjects(crds)...)
+
+			if err := istio.reportResults(opID, istio.applyResources(ctx, crds, true, "", nil)); err != nil {
+				return status.Removing, ErrAddonFromTemplate(err)
+			}
+		}
+	}
+
+	if timeout := opts.readinessTimeout(); timeout > 0 && len(objects) > 0 {
+		if err := istio.waitForAddonRemoval(ctx, opID, namespace, objects, timeout); err != nil {
+			return status.Removing, err
+		}
+	}
+
+	return status.Removed, nil
+}
+
+// waitForAddonRemoval waits for the objects of the addon to be gone from the
+// cluster, the objects left behind when the timeout expires are reported
+func (istio *Istio) waitForAddonRemoval(ctx context.Context, opID, namespace string, objects []manifestObject, timeout time.Duration) error {
+	istio.streamProgress(opID, "Verifying the removal", fmt.Sprintf("Waiting up to %s for %d objects of the addon to be removed", timeout, len(objects)))
+
+	client, err := dynamic.NewForConfig(&istio.RestConfig)
+	if err != nil {
+		return ErrAddonLeftovers(err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&istio.RestConfig)
+	if err != nil {
+		return ErrAddonLeftovers(err)
+	}
+
+	left := objects
+	err = wait.PollImmediate(readinessPollInterval, timeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		// Removed CRDs take their kinds away, the mappings are read again on every poll
+		groups, err := restmapper.GetAPIGroupResources(discoveryClient)
+		if err != nil {
+			istio.Log.Warn(ErrAddonLeftovers(err))
+			return false, nil
+		}
+
+		current, err := leftoverObjects(ctx, client, restmapper.NewDiscoveryRESTMapper(groups), namespace, objects)
+		if err != nil {
+			istio.Log.Warn(ErrAddonLeftovers(err))
+			return false, nil
+		}
+		left = current
+
+		return len(left) == 0, nil
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			err = fmt.Errorf("timed out after %s, left behind: %s", timeout, formatObjects(left))
+		}
+		return ErrAddonLeftovers(err)
+	}
+
+	return nil
+}
+
+// leftoverObjects returns the objects which still exist in the cluster. The
+// namespaced objects are looked up in the namespace the addon was installed to
+func leftoverObjects(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, namespace string, objects []manifestObject) ([]manifestObject, error) {
+	var left []manifestObject
+	for _, obj := range objects {
+		gv, err := schema.ParseGroupVersion(obj.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: obj.Kind}, gv.Version)
+		if meta.IsNoMatchError(err) {
+			// The kind isn't served anymore, so are its objects
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resource := dynamic.ResourceInterface(client.Resource(mapping.Resource))
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			resource = client.Resource(mapping.Resource).Namespace(namespace)
+		}
+
+		_, err = resource.Get(ctx, obj.Metadata.Name, metav1.GetOptions{})
+		if kubeerror.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		left = append(left, obj)
+	}
+
+	return left, nil
+}
+
+// manifestObjects returns the objects of the manifest
+func manifestObjects(manifest string) []manifestObject {
+	var objects []manifestObject
+	for _, doc := range splitManifest(manifest) {
+		obj := manifestObject{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" || obj.Metadata.Name == "" {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects
+}
+
+// addonCRDManifest returns the manifest of the CRDs of the given comma
+// separated names, only the names are needed to remove them
+func addonCRDManifest(names string) string {
+	var docs []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			docs = append(docs, fmt.Sprintf("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: %s\n", name))
+		}
+	}
+
+	return strings.Join(docs, "---\n")
+}
+
+// formatObjects renders the objects for an event
+func formatObjects(objects []manifestObject) string {
+	names := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		names = append(names, fmt.Sprintf("%s %s", obj.Kind, obj.Metadata.Name))
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
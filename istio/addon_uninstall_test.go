@@ -0,0 +1,62 @@
+package istio
+
+import (
+	"testing"
+)
+
+func Test_manifestObjects(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: kiali\n" +
+		"---\n# comment only\n" +
+		"---\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: monitoringdashboards.monitoring.kiali.io\n" +
+		"---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: kiali\n  namespace: istio-system\n"
+
+	got := manifestObjects(manifest)
+	if len(got) != 3 {
+		t.Fatalf("manifestObjects() returned %d objects, want 3", len(got))
+	}
+	if got[2].APIVersion != "apps/v1" || got[2].Kind != "Deployment" || got[2].Metadata.Namespace != "istio-system" {
+		t.Errorf("manifestObjects()[2] = %+v", got[2])
+	}
+
+	if left := manifestObjects(withoutKinds(manifest, "CustomResourceDefinition")); len(left) != 2 {
+		t.Errorf("manifestObjects() without CRDs returned %d objects, want 2", len(left))
+	}
+
+	want := "CustomResourceDefinition monitoringdashboards.monitoring.kiali.io, Deployment kiali, ServiceAccount kiali"
+	if formatted := formatObjects(got); formatted != want {
+		t.Errorf("formatObjects() = %q, want %q", formatted, want)
+	}
+}
+
+func Test_addonCRDManifest(t *testing.T) {
+	tests := []struct {
+		name  string
+		names string
+		want  []string
+	}{
+		{name: "no CRDs"},
+		{
+			name:  "single CRD",
+			names: "monitoringdashboards.monitoring.kiali.io",
+			want:  []string{"monitoringdashboards.monitoring.kiali.io"},
+		},
+		{
+			name:  "padded list",
+			names: " a.example.com, ,b.example.com ",
+			want:  []string{"a.example.com", "b.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := manifestObjects(addonCRDManifest(tt.names))
+			if len(got) != len(tt.want) {
+				t.Fatalf("addonCRDManifest() returned %d CRDs, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Kind != "CustomResourceDefinition" || got[i].APIVersion != "apiextensions.k8s.io/v1" || got[i].Metadata.Name != tt.want[i] {
+					t.Errorf("addonCRDManifest()[%d] = %+v, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -3,63 +3,142 @@ package istio
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
+	"time"
 
-	"github.com/layer5io/meshery-adapter-library/adapter"
 	"github.com/layer5io/meshery-adapter-library/status"
-	"github.com/layer5io/meshkit/utils"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"github.com/layer5io/meshery-istio/internal/config"
+	"gopkg.in/yaml.v2"
 )
 
-// installAddon installs/uninstalls an addon in the given namespace
+// defaultExposure is the exposure mode of the addons unless the operation
+// configures another one, the addons aren't published outside the cluster
+// unless asked for
+const defaultExposure = exposeClusterIP
+
+// addonOptions holds the user supplied settings for the addon operations
+type addonOptions struct {
+	// Version pins the istio release the addon manifests are taken from,
+	// the release of the installed control plane is used by default
+	Version string `yaml:"version,omitempty"`
+	// Expose is how the addon is made reachable, one of ClusterIP, NodePort,
+	// LoadBalancer or Gateway
+	Expose string `yaml:"expose,omitempty"`
+	// NodePort is the node port of the addon for the NodePort mode, within
+	// 30000-32767. A free one is picked by kubernetes by default
+	NodePort int32 `yaml:"nodePort,omitempty"`
+	// Host is the host the addon is routed from for the Gateway mode
+	Host string `yaml:"host,omitempty"`
+	// ReadinessTimeout is the time the addon gets to become ready after
+	// the install, "0s" skips the readiness verification
+	ReadinessTimeout string `yaml:"readinessTimeout,omitempty"`
+	// RemoveCRDs removes the CRDs of the addon along with it on uninstall,
+	// they are kept by default as other workloads may still use them
+	RemoveCRDs bool `yaml:"removeCRDs,omitempty"`
+	// CRDs are the comma separated names of the CRDs installed with the
+	// addon, as configured by the operation
+	CRDs string `yaml:"-"`
+}
+
+// parseAddonOptions reads the addon options from the additional properties
+// of the addon operation, overridden by the given yaml (or json) document
+func parseAddonOptions(props map[string]string, body string) (addonOptions, error) {
+	opts := addonOptions{
+		Expose:           props[config.AddonExposure],
+		ReadinessTimeout: props[config.ReadinessTimeout],
+		CRDs:             props[config.AddonCRDs],
+	}
+	if strings.TrimSpace(body) != "" {
+		if err := yaml.Unmarshal([]byte(body), &opts); err != nil {
+			return opts, ErrAddonInvalidConfig(err)
+		}
+	}
+
+	if opts.Version != "" {
+		if _, ok := addonReleaseTag(opts.Version); !ok {
+			return opts, ErrAddonInvalidConfig(fmt.Errorf("istio %s doesn't ship addon manifests, %d.%d or later is required", opts.Version, minAddonRelease.Major, minAddonRelease.Minor))
+		}
+	}
+
+	if opts.Expose == "" {
+		opts.Expose = defaultExposure
+	}
+	if err := validateExposure(&opts); err != nil {
+		return opts, ErrAddonInvalidConfig(err)
+	}
+
+	if opts.ReadinessTimeout != "" {
+		if _, err := time.ParseDuration(opts.ReadinessTimeout); err != nil {
+			return opts, ErrAddonInvalidConfig(err)
+		}
+	}
+
+	return opts, nil
+}
+
+// readinessTimeout returns the time the addon gets to become ready, zero
+// disables the readiness verification
+func (o addonOptions) readinessTimeout() time.Duration {
+	if o.ReadinessTimeout == "" {
+		return defaultReadinessTimeout
+	}
+
+	// The timeout has been validated by parseAddonOptions
+	d, _ := time.ParseDuration(o.ReadinessTimeout)
+	return d
+}
+
+// installAddon installs/uninstalls an addon in the given namespace, exposes
+// its service as configured by the options and waits for it to become ready
 //
-// the template defines the manifest's link/location which needs to be used to
-// install the addon
-func (istio *Istio) installAddon(namespace string, del bool, service string, patches []string, templates []adapter.Template) (string, error) {
+// the manifests are the addon resources matching the istio release, as
+// returned by addonManifests. The endpoint of the installed addon is returned
+func (istio *Istio) installAddon(ctx context.Context, opID, namespace string, del bool, service string, manifests []string, opts addonOptions) (string, string, error) {
 	st := status.Installing
 
 	if del {
 		st = status.Removing
 	}
 
+	// Addons are installed next to the control plane
 	istio.Log.Debug(fmt.Sprintf("Overidden namespace: %s", namespace))
-	namespace = "istio-system"
+	namespace = istio.findControlPlaneNamespace(ctx, "")
 
-	for _, template := range templates {
+	if del {
+		st, err := istio.uninstallAddon(ctx, opID, namespace, service, manifests, opts)
+		return st, "", err
+	}
+
+	for _, manifest := range manifests {
 		if istio.KubeClient == nil {
-			return st, ErrNilClient
+			return st, "", ErrNilClient
 		}
-		err := istio.applyManifest([]byte(template.String()), del, namespace)
-		// Specifically choosing to ignore kiali dashboard's error.
-		// Referring to: https://github.com/kiali/kiali/issues/3112
-		if err != nil && !strings.Contains(err.Error(), "no matches for kind \"MonitoringDashboard\" in version \"monitoring.kiali.io/v1alpha1\"") {
-			if !strings.Contains(err.Error(), "clusterIP") {
-				return st, ErrAddonFromTemplate(err)
-			}
+		results := istio.applyResources(ctx, manifest, false, namespace, addonIgnoreRules)
+		if err := istio.reportResults(opID, results); err != nil {
+			return st, "", ErrAddonFromTemplate(err)
 		}
 	}
 
-	for _, patch := range patches {
-		if !del {
-			_, err := url.ParseRequestURI(patch)
-			if err != nil {
-				return st, ErrAddonFromTemplate(err)
-			}
-
-			content, err := utils.ReadFileSource(patch)
-			if err != nil {
-				return st, ErrAddonFromTemplate(err)
-			}
-
-			_, err = istio.KubeClient.CoreV1().Services(namespace).Patch(context.TODO(), service, types.MergePatchType, []byte(content), metav1.PatchOptions{})
-			if err != nil {
-				return st, ErrAddonFromTemplate(err)
-			}
+	if service == "" {
+		return status.Installed, "", nil
+	}
+	if istio.KubeClient == nil {
+		return st, "", ErrNilClient
+	}
+
+	endpoint, err := istio.exposeAddon(ctx, namespace, service, opts)
+	if err != nil {
+		return st, "", ErrAddonFromTemplate(err)
+	}
+
+	if timeout := opts.readinessTimeout(); timeout > 0 {
+		health, err := istio.waitForAddon(ctx, opID, namespace, service, timeout)
+		if err != nil {
+			return st, endpoint, err
 		}
+		// Load balancers may have been assigned an address in the meantime
+		endpoint = health.Endpoint
 	}
 
-	return status.Installed, nil
+	return status.Installed, endpoint, nil
 }
@@ -1,10 +1,12 @@
 package istio
 
 import (
+	"context"
 	"testing"
 
 	"github.com/layer5io/meshery-adapter-library/adapter"
 	"github.com/layer5io/meshery-adapter-library/status"
+	"github.com/layer5io/meshery-istio/internal/config"
 )
 
 func TestIstio_installAddon(t *testing.T) {
@@ -15,8 +17,8 @@ func TestIstio_installAddon(t *testing.T) {
 		namespace string
 		del       bool
 		service   string
-		patches   []string
-		templates []adapter.Template
+		manifests []string
+		opts      addonOptions
 	}
 
 	ch := make(chan interface{}, 10)
@@ -38,29 +40,27 @@ func TestIstio_installAddon(t *testing.T) {
 	}{
 		// TODO: Add test cases.
 		{
-			name:   "no patches",
+			name:   "nil client",
 			fields: fs,
 			args: args{
 				namespace: "default",
 				del:       false,
 				service:   "test",
-				patches:   nil,
-				templates: []adapter.Template{
-					"https://raw.githubusercontent.com/istio/istio/master/samples/addons/jaeger.yaml",
+				manifests: []string{
+					"apiVersion: v1\nkind: Service\nmetadata:\n  name: tracing\n",
 				},
 			},
 			want:    status.Installing,
 			wantErr: true,
 		},
 		{
-			name:   "no templates",
+			name:   "no manifests",
 			fields: fs,
 			args: args{
 				namespace: "default",
 				del:       false,
-				service:   "test",
-				patches:   nil,
-				templates: nil,
+				service:   "",
+				manifests: nil,
 			},
 			want:    status.Installed,
 			wantErr: false,
@@ -72,19 +72,31 @@ func TestIstio_installAddon(t *testing.T) {
 				namespace: "default",
 				del:       true,
 				service:   "test",
-				patches:   nil,
-				templates: nil,
+				manifests: nil,
 			},
-			want:    status.Installed,
+			want:    status.Removed,
 			wantErr: false,
 		},
+		{
+			name:   "exposure without client",
+			fields: fs,
+			args: args{
+				namespace: "default",
+				del:       false,
+				service:   "test",
+				manifests: nil,
+				opts:      addonOptions{Expose: exposeClusterIP},
+			},
+			want:    status.Installing,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			istio := &Istio{
 				Adapter: tt.fields.Adapter,
 			}
-			got, err := istio.installAddon(tt.args.namespace, tt.args.del, tt.args.service, tt.args.patches, tt.args.templates)
+			got, _, err := istio.installAddon(context.Background(), "", tt.args.namespace, tt.args.del, tt.args.service, tt.args.manifests, tt.args.opts)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Istio.installAddon() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -95,3 +107,138 @@ func TestIstio_installAddon(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseAddonOptions(t *testing.T) {
+	props := map[string]string{config.AddonExposure: "LoadBalancer"}
+
+	tests := []struct {
+		name    string
+		props   map[string]string
+		body    string
+		want    addonOptions
+		wantErr bool
+	}{
+		{
+			name:  "empty body",
+			props: props,
+			want:  addonOptions{Expose: exposeLoadBalancer},
+		},
+		{
+			name: "no configured exposure",
+			want: addonOptions{Expose: defaultExposure},
+		},
+		{
+			name:  "pinned version",
+			props: props,
+			body:  `{"version": "1.8.1"}`,
+			want:  addonOptions{Version: "1.8.1", Expose: exposeLoadBalancer},
+		},
+		{
+			name:    "release without addon manifests",
+			props:   props,
+			body:    "version: 1.6.8",
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			props:   props,
+			body:    "version: latest",
+			wantErr: true,
+		},
+		{
+			name:    "malformed body",
+			props:   props,
+			body:    "version: [",
+			wantErr: true,
+		},
+		{
+			name:  "cluster ip",
+			props: props,
+			body:  "expose: clusterip",
+			want:  addonOptions{Expose: exposeClusterIP},
+		},
+		{
+			name:  "node port",
+			props: props,
+			body:  `{"expose": "NodePort", "nodePort": 30090}`,
+			want:  addonOptions{Expose: exposeNodePort, NodePort: 30090},
+		},
+		{
+			name:    "node port of another mode",
+			props:   props,
+			body:    `{"expose": "LoadBalancer", "nodePort": 30090}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid node port",
+			props:   props,
+			body:    `{"expose": "NodePort", "nodePort": 70000}`,
+			wantErr: true,
+		},
+		{
+			name:    "node port outside of the node port range",
+			props:   props,
+			body:    `{"expose": "NodePort", "nodePort": 8080}`,
+			wantErr: true,
+		},
+		{
+			name:  "gateway",
+			props: props,
+			body:  "expose: Gateway\nhost: grafana.example.com",
+			want:  addonOptions{Expose: exposeGateway, Host: "grafana.example.com"},
+		},
+		{
+			name:    "gateway without host",
+			props:   props,
+			body:    "expose: Gateway",
+			wantErr: true,
+		},
+		{
+			name:    "host of another mode",
+			props:   props,
+			body:    "expose: NodePort\nhost: grafana.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "invalid host",
+			props:   props,
+			body:    "expose: Gateway\nhost: grafana example",
+			wantErr: true,
+		},
+		{
+			name:  "readiness timeout",
+			props: map[string]string{config.ReadinessTimeout: "5m"},
+			body:  "readinessTimeout: 0s",
+			want:  addonOptions{Expose: defaultExposure, ReadinessTimeout: "0s"},
+		},
+		{
+			name:    "invalid readiness timeout",
+			props:   props,
+			body:    "readinessTimeout: soon",
+			wantErr: true,
+		},
+		{
+			name:  "CRD removal",
+			props: map[string]string{config.AddonCRDs: "monitoringdashboards.monitoring.kiali.io"},
+			body:  "removeCRDs: true",
+			want:  addonOptions{Expose: defaultExposure, RemoveCRDs: true, CRDs: "monitoringdashboards.monitoring.kiali.io"},
+		},
+		{
+			name:    "unknown mode",
+			props:   props,
+			body:    "expose: Ingress",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAddonOptions(tt.props, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddonOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseAddonOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package istio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+
+	kubeerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// applyErrorClass is the kind of failure applying a resource ran into
+type applyErrorClass string
+
+// Classes of the failures applying a resource
+const (
+	applyMissingCRD applyErrorClass = "missing CRD"
+	applyImmutable  applyErrorClass = "immutable field"
+	applyConflict   applyErrorClass = "conflict"
+	applyForbidden  applyErrorClass = "forbidden"
+	applyNotFound   applyErrorClass = "not found"
+	applyInvalid    applyErrorClass = "invalid"
+	applyOther      applyErrorClass = "other"
+)
+
+// alreadyRemoved is the reason the resources which are gone are tolerated on delete
+const alreadyRemoved = "already removed"
+
+// ignoreRule tolerates a class of failures for the resources of a kind, the
+// failures are reported as warnings instead of failing the operation
+type ignoreRule struct {
+	Kind   string
+	Class  applyErrorClass
+	Reason string
+}
+
+// addonIgnoreRules are the failures tolerated while applying the addon manifests
+var addonIgnoreRules = []ignoreRule{
+	{
+		// Referring to: https://github.com/kiali/kiali/issues/3112
+		Kind:   "MonitoringDashboard",
+		Class:  applyMissingCRD,
+		Reason: "the kiali dashboards require the kiali operator CRDs",
+	},
+	{
+		Kind:   "Service",
+		Class:  applyImmutable,
+		Reason: "the cluster IP of an existing service can't be changed, the service is kept as is",
+	},
+}
+
+// resourceResult is the outcome of applying a resource of a manifest
+type resourceResult struct {
+	Kind  string
+	Name  string
+	Err   error
+	Class applyErrorClass
+	// Reason explains why the failure is tolerated, empty if it isn't
+	Reason string
+}
+
+// Failed reports whether the failure of the resource fails the operation
+func (r resourceResult) Failed() bool {
+	return r.Err != nil && r.Reason == ""
+}
+
+// Ignored reports whether the resource failed with a tolerated failure
+func (r resourceResult) Ignored() bool {
+	return r.Err != nil && r.Reason != ""
+}
+
+func (r resourceResult) String() string {
+	if r.Err == nil {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%s %s: %s: %s", r.Kind, r.Name, r.Class, r.Err.Error())
+}
+
+// applyResources applies (or deletes) the resources of the manifest one by
+// one and returns the outcome of each of them. Failures matching the rules,
+// and resources which are already gone on delete, are tolerated. Resources
+// are deleted in the reverse order of the manifest
+func (istio *Istio) applyResources(ctx context.Context, manifest string, del bool, namespace string, rules []ignoreRule) []resourceResult {
+	docs := splitManifest(manifest)
+	if del {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+	}
+	results := make([]resourceResult, 0, len(docs))
+
+	applier, err := istio.newResourceApplier()
+	if err != nil {
+		return append(results, resourceResult{Kind: "manifest", Err: err, Class: classifyApplyError(err)})
+	}
+
+	for _, doc := range docs {
+		obj := &unstructured.Unstructured{}
+		if err := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(doc), len(doc)).Decode(&obj.Object); err != nil {
+			results = append(results, resourceResult{Kind: "unknown", Err: err, Class: applyInvalid})
+			continue
+		}
+
+		result := resourceResult{Kind: obj.GetKind(), Name: obj.GetName()}
+		if err := applier.apply(ctx, obj, del, namespace); err != nil {
+			result.Err = err
+			result.Class = classifyApplyError(err)
+			result.Reason = ignoreReason(rules, result.Kind, result.Class, del)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// resourceApplier applies resources through the dynamic client, unlike the
+// meshkit client it hands back the api errors with their type intact
+type resourceApplier struct {
+	client dynamic.Interface
+	mapper *restmapper.DeferredDiscoveryRESTMapper
+}
+
+func (istio *Istio) newResourceApplier() (*resourceApplier, error) {
+	client, err := dynamic.NewForConfig(&istio.RestConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&istio.RestConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourceApplier{
+		client: client,
+		mapper: restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+	}, nil
+}
+
+// apply creates, updates or deletes the object. Namespaced objects go to the
+// given namespace, or to their own one if it is empty
+func (a *resourceApplier) apply(ctx context.Context, obj *unstructured.Unstructured, del bool, namespace string) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The kind may come from a CRD applied earlier in the manifest
+		a.mapper.Reset()
+		mapping, err = a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	resource := dynamic.ResourceInterface(a.client.Resource(mapping.Resource))
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		obj.SetNamespace(namespace)
+		resource = a.client.Resource(mapping.Resource).Namespace(namespace)
+	}
+
+	if del {
+		propagation := metav1.DeletePropagationBackground
+		return resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+	}
+
+	current, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if kubeerror.IsNotFound(err) {
+		_, err = resource.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(current.GetResourceVersion())
+	_, err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+// classifyApplyError returns the class of the failure, read from the api
+// status or the mapping error found in the error chain
+func classifyApplyError(err error) applyErrorClass {
+	var (
+		kindErr     *meta.NoKindMatchError
+		resourceErr *meta.NoResourceMatchError
+	)
+	if errors.As(err, &kindErr) || errors.As(err, &resourceErr) {
+		return applyMissingCRD
+	}
+
+	var status kubeerror.APIStatus
+	if !errors.As(err, &status) {
+		return applyOther
+	}
+
+	switch status.Status().Reason {
+	case metav1.StatusReasonConflict, metav1.StatusReasonAlreadyExists:
+		return applyConflict
+	case metav1.StatusReasonForbidden:
+		return applyForbidden
+	case metav1.StatusReasonNotFound:
+		return applyNotFound
+	case metav1.StatusReasonInvalid:
+		if details := status.Status().Details; details != nil {
+			for _, cause := range details.Causes {
+				if strings.Contains(cause.Message, "field is immutable") {
+					return applyImmutable
+				}
+			}
+		}
+		return applyInvalid
+	}
+
+	return applyOther
+}
+
+// ignoreReason returns the reason the failure is tolerated, empty if none
+// of the rules covers it
+func ignoreReason(rules []ignoreRule, kind string, class applyErrorClass, del bool) string {
+	if del && (class == applyNotFound || class == applyMissingCRD) {
+		return alreadyRemoved
+	}
+
+	for _, rule := range rules {
+		if rule.Kind == kind && rule.Class == class {
+			return rule.Reason
+		}
+	}
+
+	return ""
+}
+
+// reportResults streams the tolerated failures as warnings and returns the
+// failures which fail the operation, if any. Resources which are already
+// gone on delete are only logged
+func (istio *Istio) reportResults(opID string, results []resourceResult) error {
+	var failures []string
+	for _, result := range results {
+		switch {
+		case result.Failed():
+			failures = append(failures, result.String())
+		case result.Reason == alreadyRemoved:
+			istio.Log.Debug(fmt.Sprintf("%s is %s", result.String(), alreadyRemoved))
+		case result.Ignored():
+			istio.warnResult(opID, result)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// warnResult reports a tolerated failure
+func (istio *Istio) warnResult(opID string, result resourceResult) {
+	err := ErrAddonFromTemplate(fmt.Errorf("%s", result.String()))
+	if opID == "" {
+		istio.Log.Warn(err)
+		return
+	}
+
+	istio.StreamWarn(&adapter.Event{
+		Operationid: opID,
+		Summary:     fmt.Sprintf("Skipped %s %s", result.Kind, result.Name),
+		Details:     fmt.Sprintf("%s (%s)", result.String(), result.Reason),
+	}, err)
+}
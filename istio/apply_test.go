@@ -0,0 +1,112 @@
+package istio
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	kubeerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_classifyApplyError(t *testing.T) {
+	services := schema.GroupResource{Resource: "services"}
+	immutable := field.ErrorList{field.Invalid(field.NewPath("spec", "clusterIP"), "", "field is immutable")}
+
+	tests := []struct {
+		name string
+		err  error
+		want applyErrorClass
+	}{
+		{
+			name: "typed conflict",
+			err:  kubeerror.NewConflict(services, "grafana", errors.New("the object has been modified")),
+			want: applyConflict,
+		},
+		{
+			name: "typed forbidden",
+			err:  kubeerror.NewForbidden(services, "grafana", errors.New("denied")),
+			want: applyForbidden,
+		},
+		{
+			name: "typed not found",
+			err:  kubeerror.NewNotFound(services, "grafana"),
+			want: applyNotFound,
+		},
+		{
+			name: "typed already exists",
+			err:  kubeerror.NewAlreadyExists(services, "grafana"),
+			want: applyConflict,
+		},
+		{
+			name: "typed immutable field",
+			err:  kubeerror.NewInvalid(schema.GroupKind{Kind: "Service"}, "grafana", immutable),
+			want: applyImmutable,
+		},
+		{
+			name: "typed invalid",
+			err:  kubeerror.NewInvalid(schema.GroupKind{Kind: "Service"}, "grafana", field.ErrorList{field.Required(field.NewPath("spec", "ports"), "")}),
+			want: applyInvalid,
+		},
+		{
+			name: "missing CRD",
+			err:  &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "monitoring.kiali.io", Kind: "MonitoringDashboard"}, SearchedVersions: []string{"v1alpha1"}},
+			want: applyMissingCRD,
+		},
+		{
+			name: "wrapped not found",
+			err:  fmt.Errorf("deleting grafana: %w", kubeerror.NewNotFound(services, "grafana")),
+			want: applyNotFound,
+		},
+		{
+			name: "wrapped missing CRD",
+			err:  fmt.Errorf("applying dashboards: %w", &meta.NoKindMatchError{GroupKind: schema.GroupKind{Kind: "MonitoringDashboard"}}),
+			want: applyMissingCRD,
+		},
+		{
+			name: "message without api status",
+			err:  errors.New(`deployment "grafana" not found`),
+			want: applyOther,
+		},
+		{
+			name: "unknown failure",
+			err:  errors.New("connection refused"),
+			want: applyOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyApplyError(tt.err); got != tt.want {
+				t.Errorf("classifyApplyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ignoreReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		class   applyErrorClass
+		del     bool
+		ignored bool
+	}{
+		{name: "kiali dashboards without CRD", kind: "MonitoringDashboard", class: applyMissingCRD, ignored: true},
+		{name: "immutable service", kind: "Service", class: applyImmutable, ignored: true},
+		{name: "immutable deployment", kind: "Deployment", class: applyImmutable},
+		{name: "forbidden service", kind: "Service", class: applyForbidden},
+		{name: "missing CRD of another kind", kind: "Gateway", class: applyMissingCRD},
+		{name: "already deleted", kind: "Deployment", class: applyNotFound, del: true, ignored: true},
+		{name: "not found on install", kind: "Deployment", class: applyNotFound},
+		{name: "deleted with its CRD", kind: "VirtualService", class: applyMissingCRD, del: true, ignored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ignoreReason(addonIgnoreRules, tt.kind, tt.class, tt.del) != ""; got != tt.ignored {
+				t.Errorf("ignoreReason() ignored = %v, want %v", got, tt.ignored)
+			}
+		})
+	}
+}
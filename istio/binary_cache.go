@@ -0,0 +1,210 @@
+package istio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-istio/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// binaryInUseWindow is the time a binary is considered in use after it was
+// last handed out. Operations run istioctl without holding the release lock,
+// the window outlasts their timeout so that their binary isn't pruned
+const binaryInUseWindow = 30 * time.Minute
+
+// cachedBinary describes an istioctl release kept in the bin directory
+type cachedBinary struct {
+	Release  string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// cachePolicy bounds the number and the total size of the cached binaries,
+// zero values leave the respective dimension unbounded
+type cachePolicy struct {
+	MaxEntries int   `yaml:"maxEntries,omitempty"`
+	MaxBytes   int64 `yaml:"maxBytes,omitempty"`
+}
+
+// cachePolicyFromProperties reads the cache policy from the additional properties
+// of the prune operation, overridden by the given yaml (or json) document
+func cachePolicyFromProperties(props map[string]string, body string) (cachePolicy, error) {
+	policy := cachePolicy{}
+
+	var err error
+	if v := props[config.CacheMaxEntries]; v != "" {
+		if policy.MaxEntries, err = strconv.Atoi(v); err != nil {
+			return policy, ErrBinaryCache(err)
+		}
+	}
+	if v := props[config.CacheMaxBytes]; v != "" {
+		if policy.MaxBytes, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return policy, ErrBinaryCache(err)
+		}
+	}
+
+	if strings.TrimSpace(body) != "" {
+		if err := yaml.Unmarshal([]byte(body), &policy); err != nil {
+			return policy, ErrBinaryCache(err)
+		}
+	}
+
+	if policy.MaxEntries < 0 || policy.MaxBytes < 0 {
+		return policy, ErrBinaryCache(fmt.Errorf("cache limits can't be negative"))
+	}
+
+	return policy, nil
+}
+
+// evictions returns the binaries which have to be removed to satisfy
+// the policy, least recently used ones go first
+func (p cachePolicy) evictions(binaries []cachedBinary) []cachedBinary {
+	sorted := make([]cachedBinary, len(binaries))
+	copy(sorted, binaries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastUsed.After(sorted[j].LastUsed)
+	})
+
+	var (
+		evict []cachedBinary
+		kept  int
+		size  int64
+	)
+	for _, binary := range sorted {
+		if (p.MaxEntries > 0 && kept+1 > p.MaxEntries) || (p.MaxBytes > 0 && size+binary.Size > p.MaxBytes) {
+			evict = append(evict, binary)
+			continue
+		}
+		kept++
+		size += binary.Size
+	}
+
+	return evict
+}
+
+// listCachedBinaries returns the istioctl releases downloaded to binPath,
+// most recently used first
+func listCachedBinaries(binPath string) ([]cachedBinary, error) {
+	files, err := ioutil.ReadDir(binPath)
+	if err != nil {
+		return nil, ErrBinaryCache(err)
+	}
+
+	binaries := []cachedBinary{}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".exe")
+		if !file.Mode().IsRegular() || !strings.HasPrefix(name, "istioctl-") {
+			continue
+		}
+
+		binaries = append(binaries, cachedBinary{
+			Release:  strings.TrimPrefix(name, "istioctl-"),
+			Path:     filepath.Join(binPath, file.Name()),
+			Size:     file.Size(),
+			LastUsed: file.ModTime(),
+		})
+	}
+
+	sort.SliceStable(binaries, func(i, j int) bool {
+		return binaries[i].LastUsed.After(binaries[j].LastUsed)
+	})
+
+	return binaries, nil
+}
+
+// pruneBinaryCache removes the binaries exceeding the policy from binPath
+// and returns the removed ones. The binary of the keep release and the ones
+// used within binaryInUseWindow are never removed, releases being downloaded
+// are waited for
+func pruneBinaryCache(binPath string, policy cachePolicy, keep string) ([]cachedBinary, error) {
+	binaries, err := listCachedBinaries(binPath)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []cachedBinary{}
+	for _, binary := range policy.evictions(binaries) {
+		if binary.Release == keep {
+			continue
+		}
+
+		unlock, err := lockRelease(binPath, binary.Release)
+		if err != nil {
+			return removed, err
+		}
+
+		// The binary is handed out under the lock, its use is checked again
+		if info, err := os.Stat(binary.Path); err == nil && time.Since(info.ModTime()) < binaryInUseWindow {
+			unlock()
+			continue
+		}
+
+		err = os.Remove(binary.Path)
+		unlock()
+		if err != nil && !os.IsNotExist(err) {
+			return removed, ErrBinaryCache(err)
+		}
+
+		removed = append(removed, binary)
+	}
+
+	return removed, nil
+}
+
+// touchBinary records the use of a cached binary
+func touchBinary(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// pruneBinaries applies the cache policy of the prune operation to
+// the bin directory, keeping the binary of the given release
+func (istio *Istio) pruneBinaries(binPath, keep string) {
+	operations := make(adapter.Operations)
+	if err := istio.Config.GetObject(adapter.OperationsKey, &operations); err != nil {
+		istio.Log.Warn(ErrBinaryCache(err))
+		return
+	}
+
+	op, ok := operations[config.IstioctlCachePruneOperation]
+	if !ok {
+		return
+	}
+
+	policy, err := cachePolicyFromProperties(op.AdditionalProperties, "")
+	if err != nil {
+		istio.Log.Warn(err)
+		return
+	}
+
+	removed, err := pruneBinaryCache(binPath, policy, keep)
+	if err != nil {
+		istio.Log.Warn(err)
+	}
+	for _, binary := range removed {
+		istio.Log.Info("Removed cached istioctl ", binary.Release)
+	}
+}
+
+// formatCachedBinaries renders the cached binaries for an event
+func formatCachedBinaries(binaries []cachedBinary) string {
+	if len(binaries) == 0 {
+		return "No istioctl binaries are cached"
+	}
+
+	lines := make([]string, 0, len(binaries))
+	for _, binary := range binaries {
+		lines = append(lines, fmt.Sprintf("istioctl %s: %.1f MB, last used %s", binary.Release, float64(binary.Size)/(1<<20), binary.LastUsed.Format(time.RFC3339)))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package istio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_cachePolicy_evictions(t *testing.T) {
+	now := time.Now()
+	binaries := []cachedBinary{
+		{Release: "1.7.6", Size: 100, LastUsed: now.Add(-3 * time.Hour)},
+		{Release: "1.8.1", Size: 100, LastUsed: now},
+		{Release: "1.8.0", Size: 100, LastUsed: now.Add(-time.Hour)},
+		{Release: "1.6.14", Size: 100, LastUsed: now.Add(-2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		policy cachePolicy
+		want   []string
+	}{
+		{
+			name:   "unbounded",
+			policy: cachePolicy{},
+			want:   nil,
+		},
+		{
+			name:   "least recently used beyond max entries",
+			policy: cachePolicy{MaxEntries: 2},
+			want:   []string{"1.6.14", "1.7.6"},
+		},
+		{
+			name:   "least recently used beyond max bytes",
+			policy: cachePolicy{MaxBytes: 350},
+			want:   []string{"1.7.6"},
+		},
+		{
+			name:   "both limits",
+			policy: cachePolicy{MaxEntries: 3, MaxBytes: 150},
+			want:   []string{"1.8.0", "1.6.14", "1.7.6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, binary := range tt.policy.evictions(binaries) {
+				got = append(got, binary.Release)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cachePolicy.evictions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pruneBinaryCache(t *testing.T) {
+	binPath, err := ioutil.TempDir("", "istioctl-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(binPath)
+	}()
+
+	now := time.Now()
+	lastUsed := []struct {
+		release string
+		at      time.Time
+	}{
+		{"1.8.1", now},
+		// Still in use by an operation
+		{"1.9.0", now.Add(-5 * time.Minute)},
+		{"1.8.0", now.Add(-time.Hour)},
+		{"1.7.6", now.Add(-2 * time.Hour)},
+	}
+	for _, binary := range lastUsed {
+		name := filepath.Join(binPath, "istioctl-"+binary.release)
+		if err := ioutil.WriteFile(name, []byte("istioctl"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, binary.at, binary.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files which aren't cached binaries are left alone
+	if err := ioutil.WriteFile(filepath.Join(binPath, ".istioctl-1.6.14.lock"), []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := pruneBinaryCache(binPath, cachePolicy{MaxEntries: 1}, "1.7.6")
+	if err != nil {
+		t.Fatalf("pruneBinaryCache() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].Release != "1.8.0" {
+		t.Errorf("pruneBinaryCache() removed = %+v, want only 1.8.0", removed)
+	}
+
+	binaries, err := listCachedBinaries(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, binary := range binaries {
+		got = append(got, binary.Release)
+	}
+	if want := []string{"1.8.1", "1.9.0", "1.7.6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listCachedBinaries() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,421 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/status"
+	"github.com/layer5io/meshery-istio/internal/config"
+	"gopkg.in/yaml.v2"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// injectionLabel enables sidecar injection from the default control plane
+	injectionLabel = "istio-injection"
+
+	// revisionLabel binds a namespace to a specific control plane revision
+	revisionLabel = "istio.io/rev"
+
+	// defaultRevision is the revision of a control plane installed without one
+	defaultRevision = "default"
+
+	// restartAnnotation is updated on the pod template to trigger a rolling restart
+	restartAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+// canaryOptions holds the settings for a revision based upgrade
+// of the control plane
+type canaryOptions struct {
+	installOptions `yaml:",inline"`
+
+	// PreviousRevision is the revision of the control plane being replaced
+	PreviousRevision string `yaml:"previousRevision,omitempty"`
+}
+
+// parseCanaryOptions reads the canary upgrade options from the given yaml (or json)
+// document. Unless specified, the new revision is derived from the version
+// and the previous revision is the default one
+func parseCanaryOptions(body, version string) (canaryOptions, error) {
+	opts := canaryOptions{}
+	if strings.TrimSpace(body) != "" {
+		if err := yaml.Unmarshal([]byte(body), &opts); err != nil {
+			return opts, ErrInvalidInstallOptions(err)
+		}
+	}
+
+	if opts.Profile == "" {
+		opts.Profile = defaultProfile
+	}
+	if opts.Installer == "" {
+		opts.Installer = installerIstioctl
+	}
+	if opts.Installer != installerIstioctl {
+		return opts, ErrInvalidInstallOptions(fmt.Errorf("canary upgrades are only supported by the %s installer", installerIstioctl))
+	}
+	if opts.Revision == "" {
+		opts.Revision = revisionFromVersion(version)
+	}
+	if opts.PreviousRevision == "" {
+		opts.PreviousRevision = defaultRevision
+	}
+
+	if !revisionPattern.MatchString(opts.PreviousRevision) {
+		return opts, ErrInvalidInstallOptions(fmt.Errorf("invalid previous revision %q", opts.PreviousRevision))
+	}
+	if opts.PreviousRevision == opts.Revision {
+		return opts, ErrInvalidInstallOptions(fmt.Errorf("revision %q is already installed", opts.Revision))
+	}
+
+	return opts, opts.validate()
+}
+
+// revisionFromVersion derives a revision name from the istio release,
+// for example 1.8.1 becomes 1-8-1
+func revisionFromVersion(version string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(version, "v"), ".", "-")
+}
+
+// canaryUpgrade installs the given version as a new control plane revision next
+// to the existing one, waits for it to become ready, moves the labelled
+// namespaces over to it, restarts their workloads and, once their rollouts
+// completed, removes the previous revision. Each phase is streamed as an event
+// of the operation.
+//
+// On delete the namespaces are moved back to the previous revision and the
+// new revision is removed. The rollback is refused if the previous revision
+// isn't running anymore, as it would leave the mesh without a control plane
+func (istio *Istio) canaryUpgrade(ctx context.Context, opID string, del bool, version string, opts canaryOptions) (string, error) {
+	st := status.Installing
+
+	if del {
+		st = status.Removing
+	}
+
+	if istio.KubeClient == nil {
+		return st, ErrNilClient
+	}
+
+	// The new revision joins the control plane of the previous one
+	if opts.Namespace == "" && opts.Set[istioNamespaceKey] == "" {
+		opts.installOptions = opts.withNamespace(istio.findControlPlaneNamespace(ctx, opts.PreviousRevision))
+	}
+
+	timeout, err := istio.readinessTimeout(opts.installOptions)
+	if err != nil {
+		return st, ErrCanaryUpgrade(err)
+	}
+
+	from, to := opts.PreviousRevision, opts.Revision
+	if del {
+		from, to = opts.Revision, opts.PreviousRevision
+
+		planes, err := istio.discoverControlPlanes(ctx)
+		if err != nil {
+			return st, ErrCanaryUpgrade(err)
+		}
+		if !hasRevision(planes, to) {
+			return st, ErrCanaryUpgrade(fmt.Errorf("revision %s isn't running anymore, removing revision %s would leave the mesh without a control plane", to, from))
+		}
+	} else {
+		istio.streamProgress(opID, fmt.Sprintf("Installing revision %s", to), fmt.Sprintf("Installing Istio %s as revision %s next to revision %s", version, to, from))
+		if err := istio.runIstioCtlCmd(ctx, opID, version, false, opts.installOptions); err != nil {
+			return st, ErrCanaryUpgrade(err)
+		}
+		if err := istio.verifyInstall(ctx, opID, version, opts.controlPlaneNamespace(""), opts.installOptions); err != nil {
+			return st, ErrCanaryUpgrade(err)
+		}
+	}
+
+	namespaces, err := istio.namespacesForRevision(ctx, from)
+	if err != nil {
+		return st, ErrCanaryUpgrade(err)
+	}
+
+	istio.streamProgress(opID, "Relabelling namespaces", fmt.Sprintf("Moving namespaces [%s] from revision %s to revision %s", strings.Join(namespaces, ", "), from, to))
+	label := to
+	if to == defaultRevision {
+		label = ""
+	}
+	for _, ns := range namespaces {
+		if err := istio.LoadNamespaceToMesh(ns, label, false); err != nil {
+			return st, ErrCanaryUpgrade(err)
+		}
+	}
+
+	istio.streamProgress(opID, "Restarting workloads", fmt.Sprintf("Rolling restart of the deployments, statefulsets and daemonsets in namespaces [%s]", strings.Join(namespaces, ", ")))
+	for _, ns := range namespaces {
+		if err := istio.restartWorkloads(ctx, ns); err != nil {
+			return st, ErrCanaryUpgrade(err)
+		}
+	}
+
+	// The previous revision keeps serving the workloads until they are
+	// restarted, so the rollouts are waited for even if the readiness
+	// verification is skipped
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+	if err := istio.waitForRollouts(ctx, opID, namespaces, timeout); err != nil {
+		return st, ErrCanaryUpgrade(err)
+	}
+
+	istio.streamProgress(opID, fmt.Sprintf("Removing revision %s", from), fmt.Sprintf("Uninstalling the control plane of revision %s", from))
+	if err := istio.execIstioCtl(ctx, opID, version, "x", "uninstall", "--revision", from, "-y"); err != nil {
+		return st, ErrCanaryUpgrade(err)
+	}
+	istio.refreshMeshSpec(ctx)
+
+	if del {
+		return status.Removed, nil
+	}
+	return status.Installed, nil
+}
+
+// waitForRollouts waits for the rollouts of the workloads in the namespaces
+// to complete, the workloads still rolling out are reported on timeout
+func (istio *Istio) waitForRollouts(ctx context.Context, opID string, namespaces []string, timeout time.Duration) error {
+	istio.streamProgress(opID, "Waiting for the rollouts", fmt.Sprintf("Waiting up to %s for the workloads in namespaces [%s] to roll out", timeout, strings.Join(namespaces, ", ")))
+
+	var pending []string
+	err := wait.PollImmediate(readinessPollInterval, timeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		pending = nil
+		for _, ns := range namespaces {
+			apps := istio.KubeClient.AppsV1()
+			deploys, err := apps.Deployments(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				istio.Log.Warn(ErrCanaryUpgrade(err))
+				return false, nil
+			}
+			sets, err := apps.StatefulSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				istio.Log.Warn(ErrCanaryUpgrade(err))
+				return false, nil
+			}
+			daemons, err := apps.DaemonSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				istio.Log.Warn(ErrCanaryUpgrade(err))
+				return false, nil
+			}
+			pending = append(pending, pendingRollouts(deploys.Items, sets.Items, daemons.Items)...)
+		}
+
+		return len(pending) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out after %s waiting for the rollouts of [%s]", timeout, strings.Join(pending, ", "))
+	}
+
+	return err
+}
+
+// pendingRollouts returns the kinds and namespaced names of the workloads
+// whose rollout didn't complete yet. Workloads updated on delete only are
+// never rolled by a restart, they aren't waited for
+func pendingRollouts(deploys []appsv1.Deployment, sets []appsv1.StatefulSet, daemons []appsv1.DaemonSet) []string {
+	var pending []string
+	for _, deploy := range deploys {
+		if ready, _ := deploymentReady(deploy); !ready {
+			pending = append(pending, fmt.Sprintf("deployment %s/%s", deploy.Namespace, deploy.Name))
+		}
+	}
+	for _, set := range sets {
+		if !statefulSetRolledOut(set) {
+			pending = append(pending, fmt.Sprintf("statefulset %s/%s", set.Namespace, set.Name))
+		}
+	}
+	for _, daemon := range daemons {
+		if !daemonSetRolledOut(daemon) {
+			pending = append(pending, fmt.Sprintf("daemonset %s/%s", daemon.Namespace, daemon.Name))
+		}
+	}
+
+	return pending
+}
+
+// statefulSetRolledOut reports whether the rollout of the statefulset
+// completed, the way "kubectl rollout status" tells
+func statefulSetRolledOut(set appsv1.StatefulSet) bool {
+	if set.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+	if set.Status.ObservedGeneration < set.Generation {
+		return false
+	}
+
+	var desired int32 = 1
+	if set.Spec.Replicas != nil {
+		desired = *set.Spec.Replicas
+	}
+	if set.Status.ReadyReplicas < desired {
+		return false
+	}
+
+	if rolling := set.Spec.UpdateStrategy.RollingUpdate; rolling != nil && rolling.Partition != nil {
+		return set.Status.UpdatedReplicas >= desired-*rolling.Partition
+	}
+
+	return set.Status.UpdateRevision == set.Status.CurrentRevision
+}
+
+// daemonSetRolledOut reports whether the rollout of the daemonset
+// completed, the way "kubectl rollout status" tells
+func daemonSetRolledOut(daemon appsv1.DaemonSet) bool {
+	if daemon.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		return true
+	}
+	if daemon.Status.ObservedGeneration < daemon.Generation {
+		return false
+	}
+
+	desired := daemon.Status.DesiredNumberScheduled
+	return daemon.Status.UpdatedNumberScheduled >= desired && daemon.Status.NumberAvailable >= desired
+}
+
+// injectionRevision returns the revision the namespace gets injected by, the
+// default revision being the empty one. See selectInjectionRevision
+func (istio *Istio) injectionRevision(ctx context.Context, namespace string) (string, error) {
+	if istio.KubeClient == nil {
+		return "", ErrNilClient
+	}
+
+	ns, err := istio.KubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	planes, err := istio.discoverControlPlanes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return selectInjectionRevision(planes, ns.Labels[revisionLabel]), nil
+}
+
+// selectInjectionRevision picks the revision injecting a namespace: the one
+// it is bound to if still running, else the default revision, else the
+// revision of the newest release. Without any control plane the default
+// revision is picked, so the namespace is injected once it gets installed
+func selectInjectionRevision(planes []controlPlane, current string) string {
+	if current != "" && hasRevision(planes, current) {
+		return current
+	}
+	if len(planes) == 0 || hasRevision(planes, defaultRevision) {
+		return ""
+	}
+
+	newest := planes[0]
+	for _, plane := range planes[1:] {
+		if planeVersionLess(newest, plane) {
+			newest = plane
+		}
+	}
+
+	return newest.Revision
+}
+
+// planeVersionLess orders the control planes by release, the unparsable
+// versions first, then by revision
+func planeVersionLess(a, b controlPlane) bool {
+	va, errA := config.ParseReleaseVersion(strings.TrimSuffix(a.Version, "-distroless"))
+	vb, errB := config.ParseReleaseVersion(strings.TrimSuffix(b.Version, "-distroless"))
+	switch {
+	case errA != nil && errB == nil:
+		return true
+	case errA == nil && errB != nil:
+		return false
+	case errA == nil && errB == nil && va.Less(vb) != vb.Less(va):
+		return va.Less(vb)
+	}
+
+	return a.Revision < b.Revision
+}
+
+func hasRevision(planes []controlPlane, revision string) bool {
+	for _, plane := range planes {
+		if plane.Revision == revision {
+			return true
+		}
+	}
+
+	return false
+}
+
+// namespacesForRevision returns the namespaces whose sidecars are injected
+// by the given control plane revision
+func (istio *Istio) namespacesForRevision(ctx context.Context, revision string) ([]string, error) {
+	selectors := []string{fmt.Sprintf("%s=%s", revisionLabel, revision)}
+	if revision == defaultRevision {
+		selectors = append(selectors, fmt.Sprintf("%s=enabled", injectionLabel))
+	}
+
+	found := map[string]bool{}
+	for _, selector := range selectors {
+		nsList, err := istio.KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nsList.Items {
+			found[ns.Name] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(found))
+	for ns := range found {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
+
+// restartWorkloads triggers a rolling restart of all the deployments,
+// statefulsets and daemonsets in the namespace so that their pods get the
+// sidecar of the new revision
+func (istio *Istio) restartWorkloads(ctx context.Context, namespace string) error {
+	apps := istio.KubeClient.AppsV1()
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"%s":"%s"}}}}}`, restartAnnotation, time.Now().Format(time.RFC3339)))
+
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, deploy := range deployments.Items {
+		if _, err := apps.Deployments(namespace).Patch(ctx, deploy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+
+	sets, err := apps.StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, set := range sets.Items {
+		if _, err := apps.StatefulSets(namespace).Patch(ctx, set.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+
+	daemons, err := apps.DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, daemon := range daemons.Items {
+		if _, err := apps.DaemonSets(namespace).Patch(ctx, daemon.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package istio
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_parseCanaryOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		version      string
+		wantRevision string
+		wantPrevious string
+		wantErr      bool
+	}{
+		{
+			name:         "revision derived from version",
+			body:         "",
+			version:      "1.8.1",
+			wantRevision: "1-8-1",
+			wantPrevious: defaultRevision,
+		},
+		{
+			name:         "explicit revisions",
+			body:         "revision: canary\npreviousRevision: 1-7-6\nprofile: minimal",
+			version:      "1.8.1",
+			wantRevision: "canary",
+			wantPrevious: "1-7-6",
+		},
+		{
+			name:    "same revision",
+			body:    "revision: 1-8-1\npreviousRevision: 1-8-1",
+			version: "1.8.1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid previous revision",
+			body:    "previousRevision: Default",
+			version: "1.8.1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCanaryOptions(tt.body, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseCanaryOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Revision != tt.wantRevision {
+				t.Errorf("parseCanaryOptions() revision = %v, want %v", got.Revision, tt.wantRevision)
+			}
+			if got.PreviousRevision != tt.wantPrevious {
+				t.Errorf("parseCanaryOptions() previous revision = %v, want %v", got.PreviousRevision, tt.wantPrevious)
+			}
+		})
+	}
+}
+
+func Test_selectInjectionRevision(t *testing.T) {
+	canary := []controlPlane{
+		{Revision: "1-7-6", Version: "1.7.6"},
+		{Revision: "1-8-1", Version: "1.8.1"},
+		{Revision: "1-8-0", Version: "1.8.0-distroless"},
+	}
+
+	tests := []struct {
+		name    string
+		planes  []controlPlane
+		current string
+		want    string
+	}{
+		{
+			name: "no control plane",
+		},
+		{
+			name:   "default revision",
+			planes: append([]controlPlane{{Revision: defaultRevision, Version: "1.7.6"}}, canary...),
+		},
+		{
+			name:    "bound revision still running",
+			planes:  canary,
+			current: "1-7-6",
+			want:    "1-7-6",
+		},
+		{
+			name:    "bound revision removed",
+			planes:  canary,
+			current: "1-6-8",
+			want:    "1-8-1",
+		},
+		{
+			name:   "newest revision after a canary upgrade",
+			planes: canary,
+			want:   "1-8-1",
+		},
+		{
+			name:   "unknown version",
+			planes: []controlPlane{{Revision: "custom", Version: "none"}, {Revision: "1-7-6", Version: "1.7.6"}},
+			want:   "1-7-6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectInjectionRevision(tt.planes, tt.current); got != tt.want {
+				t.Errorf("selectInjectionRevision() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pendingRollouts(t *testing.T) {
+	replicas := int32(2)
+	rolledOut := appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "bookinfo", Generation: 2}}
+	rolledOut.Spec.Replicas = &replicas
+	rolledOut.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+
+	restarted := rolledOut
+	restarted.Name = "ratings"
+	restarted.Generation = 3
+
+	rolling := rolledOut
+	rolling.Name = "details"
+	rolling.Status.UpdatedReplicas = 1
+
+	set := appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "mongodb", Namespace: "bookinfo", Generation: 2}}
+	set.Spec.Replicas = &replicas
+	set.Status = appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, CurrentRevision: "mongodb-1", UpdateRevision: "mongodb-2"}
+
+	onDelete := set
+	onDelete.Name = "mysql"
+	onDelete.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+
+	daemon := appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "fluentd", Namespace: "bookinfo", Generation: 1}}
+	daemon.Status = appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}
+
+	got := pendingRollouts([]appsv1.Deployment{rolledOut, restarted, rolling}, []appsv1.StatefulSet{set, onDelete}, []appsv1.DaemonSet{daemon})
+	want := []string{"deployment bookinfo/ratings", "deployment bookinfo/details", "statefulset bookinfo/mongodb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pendingRollouts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery-istio/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// runningOperation is an in-flight operation which can be cancelled
+type runningOperation struct {
+	cancel context.CancelFunc
+}
+
+// runningOperations tracks the in-flight operations by their operation id
+var runningOperations = struct {
+	sync.Mutex
+	ops map[string]*runningOperation
+}{
+	ops: map[string]*runningOperation{},
+}
+
+// operationContext returns the context an operation runs with, bounded by the
+// timeout when positive, and registers it for cancellation by opID. The
+// returned function has to be called once the operation is done.
+//
+// The context isn't derived from the one of the request as the gRPC call
+// returns, and cancels it, as soon as the operation has been started
+func operationContext(opID string, timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+
+	op := &runningOperation{cancel: cancel}
+
+	runningOperations.Lock()
+	runningOperations.ops[opID] = op
+	runningOperations.Unlock()
+
+	return ctx, func() {
+		runningOperations.Lock()
+		if runningOperations.ops[opID] == op {
+			delete(runningOperations.ops, opID)
+		}
+		runningOperations.Unlock()
+		cancel()
+	}
+}
+
+// cancelOperation cancels the in-flight operation with the given id
+func cancelOperation(opID string) error {
+	runningOperations.Lock()
+	op, ok := runningOperations.ops[opID]
+	runningOperations.Unlock()
+
+	if !ok {
+		return ErrCancelOperation(fmt.Errorf("no running operation with id %q", opID))
+	}
+
+	op.cancel()
+	return nil
+}
+
+// cancelTarget reads the id of the operation to cancel from the custom body,
+// given either as is or as the operationId field of a yaml (or json) document
+func cancelTarget(body string) (string, error) {
+	target := struct {
+		OperationID string `yaml:"operationId"`
+	}{}
+	if err := yaml.Unmarshal([]byte(body), &target); err == nil && target.OperationID != "" {
+		return target.OperationID, nil
+	}
+
+	if id := strings.TrimSpace(body); id != "" && !strings.ContainsAny(id, " \t\r\n:{}") {
+		return id, nil
+	}
+
+	return "", ErrCancelOperation(fmt.Errorf("the id of the operation to cancel is missing"))
+}
+
+// operationTimeout reads the timeout of an operation from its additional
+// properties, overridden by the timeout field of the given yaml (or json)
+// document. A zero timeout leaves the operation unbounded
+func operationTimeout(props map[string]string, body string) (time.Duration, error) {
+	timeout := props[config.OperationTimeout]
+
+	if strings.TrimSpace(body) != "" {
+		override := struct {
+			Timeout string `yaml:"timeout"`
+		}{}
+		// Malformed documents are reported by the parsing of the operation options
+		if err := yaml.Unmarshal([]byte(body), &override); err == nil && override.Timeout != "" {
+			timeout = override.Timeout
+		}
+	}
+
+	if timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, ErrInvalidTimeout(err)
+	}
+	if d < 0 {
+		return 0, ErrInvalidTimeout(fmt.Errorf("timeout can't be negative"))
+	}
+
+	return d, nil
+}
+
+// contextError describes why the context of an operation is done
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("operation timed out")
+	case context.Canceled:
+		return fmt.Errorf("operation cancelled")
+	}
+
+	return nil
+}
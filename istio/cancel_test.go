@@ -0,0 +1,145 @@
+package istio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery-istio/internal/config"
+)
+
+func Test_operationTimeout(t *testing.T) {
+	props := map[string]string{config.OperationTimeout: "20m"}
+
+	tests := []struct {
+		name    string
+		props   map[string]string
+		body    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "no timeout",
+			props: nil,
+			want:  0,
+		},
+		{
+			name:  "operation default",
+			props: props,
+			want:  20 * time.Minute,
+		},
+		{
+			name:  "overridden by the custom body",
+			props: props,
+			body:  `{"profile": "minimal", "timeout": "90s"}`,
+			want:  90 * time.Second,
+		},
+		{
+			name:  "custom body without timeout",
+			props: props,
+			body:  "profile: minimal",
+			want:  20 * time.Minute,
+		},
+		{
+			name:    "invalid timeout",
+			props:   props,
+			body:    "timeout: soon",
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			props:   map[string]string{config.OperationTimeout: "-1m"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := operationTimeout(tt.props, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("operationTimeout() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("operationTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cancelTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain operation id",
+			body: " 5f0c7a3e-7b42-4a3c-9f0e-3a6f1c2d4b10\n",
+			want: "5f0c7a3e-7b42-4a3c-9f0e-3a6f1c2d4b10",
+		},
+		{
+			name: "operation id field",
+			body: `{"operationId": "op-1"}`,
+			want: "op-1",
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: true,
+		},
+		{
+			name:    "document without operation id",
+			body:    "profile: demo",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cancelTarget(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cancelTarget() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("cancelTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cancelOperation(t *testing.T) {
+	ctx, done := operationContext("op-cancel", 0)
+	defer done()
+
+	if err := cancelOperation("op-unknown"); err == nil {
+		t.Errorf("cancelOperation() of an unknown operation succeeded")
+	}
+
+	if err := cancelOperation("op-cancel"); err != nil {
+		t.Fatalf("cancelOperation() error = %v", err)
+	}
+	<-ctx.Done()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("operation context error = %v, want %v", ctx.Err(), context.Canceled)
+	}
+
+	done()
+	if err := cancelOperation("op-cancel"); err == nil {
+		t.Errorf("cancelOperation() of a finished operation succeeded")
+	}
+}
+
+func Test_operationContext_timeout(t *testing.T) {
+	ctx, done := operationContext("op-timeout", 10*time.Millisecond)
+	defer done()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("operation context didn't time out")
+	}
+	if err := contextError(ctx); err == nil || err.Error() != "operation timed out" {
+		t.Errorf("contextError() = %v, want operation timed out", err)
+	}
+}
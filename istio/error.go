@@ -30,10 +30,66 @@ var (
 	// during fetch manifest process
 	ErrRunIstioCtlCmdCode = "istio_test_code"
 
+	// ErrInvalidInstallOptionsCode represents the errors which are generated
+	// when the requested install options are invalid
+	ErrInvalidInstallOptionsCode = "istio_test_code"
+
+	// ErrCanaryUpgradeCode represents the errors which are generated
+	// during the revision based upgrade of the control plane
+	ErrCanaryUpgradeCode = "istio_test_code"
+
+	// ErrUpgradeIstioCode represents the errors which are generated
+	// during the in-place upgrade of the control plane
+	ErrUpgradeIstioCode = "istio_test_code"
+
+	// ErrInvalidVersionCode represents the errors which are generated
+	// when the requested istio release isn't supported
+	ErrInvalidVersionCode = "istio_test_code"
+
 	// ErrDownloadBinaryCode represents the errors which are generated
 	// during binary download process
 	ErrDownloadBinaryCode = "istio_test_code"
 
+	// ErrChecksumMismatchCode represents the errors which are generated
+	// when a downloaded archive doesn't match its published checksum
+	ErrChecksumMismatchCode = "11301"
+
+	// ErrUnsupportedPlatformCode represents the errors which are generated
+	// when no istioctl build exists for the platform of the adapter
+	ErrUnsupportedPlatformCode = "istio_test_code"
+
+	// ErrLockReleaseCode represents the errors which are generated
+	// while waiting for a concurrent download of the istio binary
+	ErrLockReleaseCode = "istio_test_code"
+
+	// ErrBinaryCacheCode represents the errors which are generated
+	// while managing the cached istio binaries
+	ErrBinaryCacheCode = "istio_test_code"
+
+	// ErrOperatorInstallCode represents the errors which are generated
+	// while installing the control plane through the istio operator
+	ErrOperatorInstallCode = "istio_test_code"
+
+	// ErrCancelOperationCode represents the errors which are generated
+	// when an operation can't be cancelled
+	ErrCancelOperationCode = "istio_test_code"
+
+	// ErrInvalidTimeoutCode represents the errors which are generated
+	// when the timeout of an operation is invalid
+	ErrInvalidTimeoutCode = "istio_test_code"
+
+	// ErrControlPlaneNotReadyCode represents the errors which are generated
+	// when the control plane doesn't become ready after the install
+	ErrControlPlaneNotReadyCode = "istio_test_code"
+
+	// ErrAddonNotReadyCode represents the errors which are generated
+	// when an addon doesn't become ready or its state can't be read
+	ErrAddonNotReadyCode = "istio_test_code"
+
+	// ErrAddonLeftoversCode represents the errors which are generated
+	// when objects of an addon are left behind by its uninstall
+	ErrAddonLeftoversCode = "istio_test_code"
+
 	// ErrInstallBinaryCode represents the errors which are generated
 	// during binary installation process
 	ErrInstallBinaryCode = "istio_test_code"
@@ -132,11 +188,81 @@ func ErrRunIstioCtlCmd(err error, des string) error {
 	return errors.NewDefault(ErrRunIstioCtlCmdCode, fmt.Sprintf("Error running istioctl command: %s", des))
 }
 
+// ErrInvalidInstallOptions is the error for invalid install options
+func ErrInvalidInstallOptions(err error) error {
+	return errors.NewDefault(ErrInvalidInstallOptionsCode, fmt.Sprintf("Invalid install options: %s", err.Error()))
+}
+
+// ErrCanaryUpgrade is the error for revision based upgrades
+func ErrCanaryUpgrade(err error) error {
+	return errors.NewDefault(ErrCanaryUpgradeCode, fmt.Sprintf("Error with canary upgrade operation: %s", err.Error()))
+}
+
+// ErrUpgradeIstio is the error for in-place upgrades
+func ErrUpgradeIstio(err error) error {
+	return errors.NewDefault(ErrUpgradeIstioCode, fmt.Sprintf("Error with istio upgrade operation: %s", err.Error()))
+}
+
+// ErrInvalidVersion is the error for unsupported istio releases
+func ErrInvalidVersion(err error) error {
+	return errors.NewDefault(ErrInvalidVersionCode, fmt.Sprintf("Invalid istio version: %s", err.Error()))
+}
+
 // ErrDownloadBinary is the error while downloading istio binary
 func ErrDownloadBinary(err error) error {
 	return errors.NewDefault(ErrDownloadBinaryCode, fmt.Sprintf("Error downloading istio binary: %s", err.Error()))
 }
 
+// ErrChecksumMismatch is the error when the downloaded archive is corrupted or tampered with
+func ErrChecksumMismatch(expected, actual string) error {
+	return errors.NewDefault(ErrChecksumMismatchCode, fmt.Sprintf("Checksum mismatch for istio binary archive: expected %s, got %s", expected, actual))
+}
+
+// ErrUnsupportedPlatform is the error when istioctl isn't built for the platform of the adapter
+func ErrUnsupportedPlatform(platform, arch, release string) error {
+	return errors.NewDefault(ErrUnsupportedPlatformCode, fmt.Sprintf("istioctl %s isn't available for %s/%s", release, platform, arch))
+}
+
+// ErrLockRelease is the error while acquiring the download lock of a release
+func ErrLockRelease(err error) error {
+	return errors.NewDefault(ErrLockReleaseCode, fmt.Sprintf("Error acquiring the istio binary download lock: %s", err.Error()))
+}
+
+// ErrBinaryCache is the error while managing the cached istio binaries
+func ErrBinaryCache(err error) error {
+	return errors.NewDefault(ErrBinaryCacheCode, fmt.Sprintf("Error with istio binary cache: %s", err.Error()))
+}
+
+// ErrOperatorInstall is the error while installing the control plane through the istio operator
+func ErrOperatorInstall(err error) error {
+	return errors.NewDefault(ErrOperatorInstallCode, fmt.Sprintf("Error with istio operator install: %s", err.Error()))
+}
+
+// ErrCancelOperation is the error when an operation can't be cancelled
+func ErrCancelOperation(err error) error {
+	return errors.NewDefault(ErrCancelOperationCode, fmt.Sprintf("Error cancelling operation: %s", err.Error()))
+}
+
+// ErrInvalidTimeout is the error for invalid operation timeouts
+func ErrInvalidTimeout(err error) error {
+	return errors.NewDefault(ErrInvalidTimeoutCode, fmt.Sprintf("Invalid operation timeout: %s", err.Error()))
+}
+
+// ErrControlPlaneNotReady is the error when the control plane doesn't become ready
+func ErrControlPlaneNotReady(err error) error {
+	return errors.NewDefault(ErrControlPlaneNotReadyCode, fmt.Sprintf("Istio control plane is not ready: %s", err.Error()))
+}
+
+// ErrAddonNotReady is the error when an addon doesn't become ready
+func ErrAddonNotReady(err error) error {
+	return errors.NewDefault(ErrAddonNotReadyCode, fmt.Sprintf("Addon is not ready: %s", err.Error()))
+}
+
+// ErrAddonLeftovers is the error when objects of an addon remain after its uninstall
+func ErrAddonLeftovers(err error) error {
+	return errors.NewDefault(ErrAddonLeftoversCode, fmt.Sprintf("Addon not completely removed: %s", err.Error()))
+}
+
 // ErrInstallBinary is the error while downloading istio binary
 func ErrInstallBinary(err error) error {
 	return errors.NewDefault(ErrInstallBinaryCode, fmt.Sprintf("Error installing istio binary: %s", err.Error()))
@@ -5,15 +5,19 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/layer5io/meshery-adapter-library/adapter"
 	"github.com/layer5io/meshery-adapter-library/status"
@@ -21,7 +25,13 @@ import (
 	mesherykube "github.com/layer5io/meshkit/utils/kubernetes"
 )
 
-func (istio *Istio) installIstio(del bool, version, namespace string) (string, error) {
+// maxBinarySize caps the size of the istioctl binary extracted from a release archive
+var maxBinarySize int64 = 512 << 20
+
+// killGracePeriod is the time istioctl gets to exit once interrupted
+const killGracePeriod = 10 * time.Second
+
+func (istio *Istio) installIstio(ctx context.Context, opID string, del bool, version, namespace string, opts installOptions) (string, error) {
 	istio.Log.Debug(fmt.Sprintf("Requested install of version: %s", version))
 	istio.Log.Debug(fmt.Sprintf("Requested action is delete: %v", del))
 	istio.Log.Debug(fmt.Sprintf("Requested action is in namespace: %s", namespace))
@@ -37,39 +47,92 @@ func (istio *Istio) installIstio(del bool, version, namespace string) (string, e
 		return st, ErrMeshConfig(err)
 	}
 
-	err = istio.runIstioCtlCmd(version, del)
+	namespace = opts.controlPlaneNamespace(namespace)
+	opts = opts.withNamespace(namespace)
+	istio.Log.Debug(fmt.Sprintf("Control plane namespace: %s", namespace))
+
+	if opts.Installer == installerOperator {
+		err = istio.installWithOperator(ctx, opID, del, version, namespace, opts)
+	} else {
+		err = istio.runIstioCtlCmd(ctx, opID, version, del, opts)
+	}
 	if err != nil {
 		istio.Log.Error(ErrInstallIstio(err))
 		return st, ErrInstallIstio(err)
 	}
 
 	if del {
+		istio.refreshMeshSpec(ctx)
 		return status.Removed, nil
 	}
+
+	if err := istio.verifyInstall(ctx, opID, version, namespace, opts); err != nil {
+		istio.Log.Error(err)
+		return st, err
+	}
+
+	istio.refreshMeshSpec(ctx)
 	return status.Installed, nil
 }
 
-func (istio *Istio) runIstioCtlCmd(version string, isDel bool) error {
-	var (
-		out bytes.Buffer
-		er  bytes.Buffer
-	)
+func (istio *Istio) runIstioCtlCmd(ctx context.Context, opID, version string, isDel bool, opts installOptions) error {
+	if isDel {
+		return istio.execIstioCtl(ctx, opID, version, "x", "uninstall", "--purge", "-y")
+	}
 
-	Executable, err := istio.getExecutable(version)
+	overlayFile, err := writeOverlay(opts.Overlay)
 	if err != nil {
 		return ErrRunIstioCtlCmd(err, err.Error())
 	}
-	execCmd := []string{"install", "--set", "profile=demo", "-y"}
-	if isDel {
-		execCmd = []string{"x", "uninstall", "--purge", "-y"}
+	if overlayFile != "" {
+		defer func() {
+			_ = os.Remove(overlayFile)
+		}()
+	}
+
+	execCmd := append([]string{"install"}, opts.args(overlayFile)...)
+	execCmd = append(execCmd, "-y")
+
+	return istio.execIstioCtl(ctx, opID, version, execCmd...)
+}
+
+// execIstioCtl runs the istioctl binary of the given release with the given arguments.
+// Each line of its output is streamed as an event of the operation identified by opID.
+// Once ctx is done istioctl is interrupted, and killed if it doesn't exit in time
+func (istio *Istio) execIstioCtl(ctx context.Context, opID, version string, args ...string) error {
+	var er bytes.Buffer
+
+	Executable, err := istio.getExecutable(ctx, version)
+	if err != nil {
+		return ErrRunIstioCtlCmd(err, err.Error())
 	}
 
 	// We need a variable executable here hence using nosec
 	// #nosec
-	command := exec.Command(Executable, execCmd...)
-	command.Stdout = &out
-	command.Stderr = &er
-	err = command.Run()
+	command := exec.Command(Executable, args...)
+	stdout := istio.istioCtlOutput(opID, subcommand(args))
+	stderr := istio.istioCtlOutput(opID, subcommand(args))
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	command.Stdout = stdout
+	command.Stderr = io.MultiWriter(&er, stderr)
+	if err = command.Start(); err != nil {
+		return ErrRunIstioCtlCmd(err, err.Error())
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- command.Wait()
+	}()
+
+	select {
+	case err = <-exited:
+	case <-ctx.Done():
+		terminateProcess(command.Process, exited)
+		err = contextError(ctx)
+		return ErrRunIstioCtlCmd(err, fmt.Sprintf("istioctl %s: %s", strings.Join(args, " "), err.Error()))
+	}
 	if err != nil {
 		return ErrRunIstioCtlCmd(err, er.String())
 	}
@@ -77,6 +140,48 @@ func (istio *Istio) runIstioCtlCmd(version string, isDel bool) error {
 	return nil
 }
 
+// terminateProcess interrupts the process and kills it unless it exits within
+// the grace period, exited receives the result of waiting for the process
+func terminateProcess(process *os.Process, exited <-chan error) {
+	// Interrupting processes isn't supported on windows
+	if err := process.Signal(os.Interrupt); err == nil {
+		select {
+		case <-exited:
+			return
+		case <-time.After(killGracePeriod):
+		}
+	}
+
+	_ = process.Kill()
+	<-exited
+}
+
+// writeOverlay stores the IstioOperator overlay in a temporary file so that
+// it can be passed on to istioctl, it returns an empty path if there is no overlay
+func writeOverlay(overlay string) (string, error) {
+	if strings.TrimSpace(overlay) == "" {
+		return "", nil
+	}
+
+	file, err := ioutil.TempFile("", "istio-overlay-*.yaml")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = file.WriteString(overlay); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+
+	if err = file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
 func (istio *Istio) applyManifest(contents []byte, isDel bool, namespace string) error {
 
 	err := istio.MesheryKubeclient.ApplyManifest(contents, mesherykube.ApplyOptions{
@@ -92,91 +197,213 @@ func (istio *Istio) applyManifest(contents []byte, isDel bool, namespace string)
 }
 
 // getExecutable looks for the executable in
-// 1. $PATH
+// 1. $PATH, where the plain istioctl is only used if it is of the release
 // 2. Root config path
 //
 // If it doesn't find the executable in the path then it proceeds
 // to download the binary from github releases and installs it
-// in the root config path
-func (istio *Istio) getExecutable(release string) (string, error) {
+// in the root config path. The download is abandoned once ctx is done
+func (istio *Istio) getExecutable(ctx context.Context, release string) (string, error) {
 	const platform = runtime.GOOS
 	binaryName := generatePlatformSpecificBinaryName("istioctl", platform)
 	alternateBinaryName := generatePlatformSpecificBinaryName("istioctl-"+release, platform)
 
 	// Look for the executable in the path
 	istio.Log.Info("Looking for istio in the path...")
-	executable, err := exec.LookPath(binaryName)
+	executable, err := exec.LookPath(alternateBinaryName)
 	if err == nil {
 		return executable, nil
 	}
-	executable, err = exec.LookPath(alternateBinaryName)
+	executable, err = exec.LookPath(binaryName)
 	if err == nil {
-		return executable, nil
+		if release == "" {
+			return executable, nil
+		}
+		if version, err := binaryVersion(ctx, executable); err == nil && sameRelease(version, release) {
+			return executable, nil
+		}
+		istio.Log.Info("Skipping ", executable, ", it isn't istioctl ", release)
 	}
 
 	binPath := path.Join(config.RootPath(), "bin")
+	if err := os.MkdirAll(binPath, 0750); err != nil {
+		return "", err
+	}
+
+	executable = path.Join(binPath, alternateBinaryName)
+	downloaded, err := istio.ensureBinary(ctx, binPath, executable, platform, binaryName, release)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the cache within its bounds once a new release has been added
+	if downloaded {
+		istio.pruneBinaries(binPath, release)
+	}
+
+	return executable, nil
+}
+
+// binaryVersion returns the client version of the istioctl executable
+func binaryVersion(ctx context.Context, executable string) (string, error) {
+	// #nosec
+	out, err := exec.CommandContext(ctx, executable, "version", "--remote=false", "--short").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// sameRelease reports whether the output of "istioctl version --short" is
+// the version of the release, which may be prefixed with "v"
+func sameRelease(output, release string) bool {
+	version := strings.TrimSpace(strings.SplitN(strings.TrimSpace(output), "\n", 2)[0])
+	return version != "" && strings.TrimPrefix(version, "v") == strings.TrimPrefix(release, "v")
+}
+
+// ensureBinary makes sure that the istioctl binary of the release exists at
+// executable, downloading it if required. Only one download of a release may
+// happen at a time, concurrent requests wait for it and reuse the binary
+func (istio *Istio) ensureBinary(ctx context.Context, binPath, executable, platform, binaryName, release string) (bool, error) {
+	unlock, err := lockRelease(binPath, release)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
 
 	// Look for config in the root path
 	istio.Log.Info("Looking for istio in", binPath, "...")
-	executable = path.Join(binPath, alternateBinaryName)
 	if _, err := os.Stat(executable); err == nil {
-		return executable, nil
+		touchBinary(executable)
+		return false, nil
 	}
 
 	// Proceed to download the binary in the config root path
 	istio.Log.Info("istio not found in the path, downloading...")
-	res, err := downloadBinary(platform, runtime.GOARCH, release)
+	archive, err := downloadBinary(ctx, platform, runtime.GOARCH, release)
 	if err != nil {
-		return "", err
+		return false, err
 	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
 	// Install the binary
 	istio.Log.Info("Installing...")
-	if err = installBinary(binPath, platform, binaryName, res); err != nil {
-		return "", err
-	}
-	// Rename the binary
-	err = os.Rename(path.Join(binPath, binaryName), path.Join(binPath, alternateBinaryName))
-	if err != nil {
-		return "", err
+	if err = installBinary(executable, platform, binaryName, archive); err != nil {
+		return false, err
 	}
 
 	istio.Log.Info("Done")
-	return path.Join(binPath, alternateBinaryName), nil
+	return true, nil
 }
 
-func downloadBinary(platform, arch, release string) (*http.Response, error) {
-	var url = "https://github.com/istio/istio/releases/download"
-	switch platform {
-	case "darwin":
-		url = fmt.Sprintf("%s/%s/istioctl-%s-osx.tar.gz", url, release, release)
-	case "windows":
-		url = fmt.Sprintf("%s/%s/istioctl-%s-win.zip", url, release, release)
-	case "linux":
-		url = fmt.Sprintf("%s/%s/istioctl-%s-%s-%s.tar.gz", url, release, release, platform, arch)
+// downloadBinary downloads the istioctl archive of the release built for the
+// platform and architecture, and verifies it against the published sha256
+// checksum. The archive is looked up in the local release cache first and
+// then fetched from the release mirror.
+//
+// The verified archive is returned as a temporary file which the caller
+// is expected to close and remove
+func downloadBinary(ctx context.Context, platform, arch, release string) (*os.File, error) {
+	asset, err := istioctlAsset(platform, arch, release)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := http.Get(url)
+	checksum, err := fetchChecksum(ctx, release, asset+".sha256")
 	if err != nil {
 		return nil, ErrDownloadBinary(err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		_ = resp.Body.Close()
-		return nil, ErrDownloadBinary(fmt.Errorf("bad status: %s", resp.Status))
+	content, err := config.OpenReleaseAsset(ctx, release, asset)
+	if err != nil {
+		return nil, ErrDownloadBinary(err)
 	}
+	defer func() {
+		if err := content.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
 
-	return resp, nil
+	return verifyArchive(content, checksum)
 }
 
-func installBinary(location, platform, name string, res *http.Response) error {
-	// Close the response body
+// fetchChecksum fetches the published sha256 checksum of a release asset
+func fetchChecksum(ctx context.Context, release, asset string) (string, error) {
+	content, err := config.OpenReleaseAsset(ctx, release, asset)
+	if err != nil {
+		return "", err
+	}
 	defer func() {
-		if err := res.Body.Close(); err != nil {
+		if err := content.Close(); err != nil {
 			fmt.Println(err)
 		}
 	}()
 
-	err := os.MkdirAll(location, 0750)
+	// The checksum file is a single line of the form "<checksum>  <file name>"
+	checksum, err := ioutil.ReadAll(io.LimitReader(content, 1024))
+	if err != nil {
+		return "", err
+	}
+
+	return parseChecksum(string(checksum))
+}
+
+// parseChecksum extracts the hex encoded sha256 checksum from the
+// contents of a checksum file
+func parseChecksum(content string) (string, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+
+	checksum := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid sha256 checksum %q", fields[0])
+	}
+
+	return checksum, nil
+}
+
+// verifyArchive writes the archive to a temporary file while computing its
+// sha256 checksum. If the checksum matches the file is returned rewound to
+// the start, otherwise it is removed and ErrChecksumMismatch is returned
+func verifyArchive(stream io.Reader, checksum string) (*os.File, error) {
+	file, err := ioutil.TempFile("", "istioctl-archive-*")
+	if err != nil {
+		return nil, ErrDownloadBinary(err)
+	}
+
+	discard := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(file, hash), stream); err != nil {
+		discard()
+		return nil, ErrDownloadBinary(err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != checksum {
+		discard()
+		return nil, ErrChecksumMismatch(checksum, actual)
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		discard()
+		return nil, ErrDownloadBinary(err)
+	}
+
+	return file, nil
+}
+
+// installBinary extracts the binary with the given name from the archive
+// and installs it at target
+func installBinary(target, platform, name string, archive io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(target), 0750)
 	if err != nil {
 		return err
 	}
@@ -185,16 +412,11 @@ func installBinary(location, platform, name string, res *http.Response) error {
 	case "darwin":
 		fallthrough
 	case "linux":
-		if err := tarxzf(location, res.Body); err != nil {
+		if err := tarxzf(target, name, archive); err != nil {
 			return ErrInstallBinary(err)
 		}
-		// Change permissions, we need the binary to be executable, hence
-		// #nosec
-		if err = os.Chmod(path.Join(location, name), 0750); err != nil {
-			return err
-		}
 	case "windows":
-		if err := unzip(location, res.Body); err != nil {
+		if err := unzip(target, name, archive); err != nil {
 			return ErrInstallBinary(err)
 		}
 	}
@@ -202,10 +424,13 @@ func installBinary(location, platform, name string, res *http.Response) error {
 	return nil
 }
 
-func tarxzf(location string, stream io.Reader) error {
+// tarxzf extracts the binary with the given name from the tar.gz stream
+// to target. Entries which would escape the target directory fail the
+// extraction, everything apart from the binary is skipped
+func tarxzf(target, name string, stream io.Reader) error {
 	uncompressedStream, err := gzip.NewReader(stream)
 	if err != nil {
-		return err
+		return ErrTarXZF(err)
 	}
 
 	tarReader := tar.NewReader(uncompressedStream)
@@ -221,98 +446,164 @@ func tarxzf(location string, stream io.Reader) error {
 			return ErrTarXZF(err)
 		}
 
-		switch header.Typeflag {
-		case tar.TypeDir:
-			// File traversal is required to store the binary at the right place
-			// #nosec
-			if err := os.MkdirAll(path.Join(location, header.Name), 0750); err != nil {
-				return ErrTarXZF(err)
-			}
-		case tar.TypeReg:
-			// File traversal is required to store the binary at the right place
-			// #nosec
-			outFile, err := os.Create(path.Join(location, header.Name))
-			if err != nil {
-				return ErrTarXZF(err)
-			}
-			// Trust istioctl tar
-			// #nosec
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				return ErrTarXZF(err)
-			}
-			if err = outFile.Close(); err != nil {
-				return ErrTarXZF(err)
-			}
+		if err := validateEntryName(header.Name); err != nil {
+			return ErrTarXZF(err)
+		}
+
+		// Directories, symlinks and any other special entries are skipped
+		if header.Typeflag != tar.TypeReg || path.Base(header.Name) != name {
+			continue
+		}
 
-		default:
+		if err := writeFileAtomic(target, tarReader); err != nil {
 			return ErrTarXZF(err)
 		}
+		return nil
 	}
 
-	return nil
+	return ErrTarXZF(fmt.Errorf("%s not found in the archive", name))
 }
 
-func unzip(location string, zippedContent io.Reader) error {
-	// Keep file in memory: Approx size ~ 50MB
-	// TODO: Find a better approach
-	zipped, err := ioutil.ReadAll(zippedContent)
+// unzip extracts the binary with the given name from the zip archive
+// to target. Entries which would escape the target directory fail the
+// extraction, everything apart from the binary is skipped
+func unzip(target, name string, zippedContent io.Reader) error {
+	zipped, cleanup, err := spoolArchive(zippedContent)
 	if err != nil {
 		return ErrUnzipFile(err)
 	}
+	defer cleanup()
 
-	zReader, err := zip.NewReader(bytes.NewReader(zipped), int64(len(zipped)))
+	zReader, err := zip.NewReader(zipped, zipped.Size())
 	if err != nil {
 		return ErrUnzipFile(err)
 	}
 
 	for _, file := range zReader.File {
+		if err := validateEntryName(file.Name); err != nil {
+			return ErrUnzipFile(err)
+		}
+
+		// Directories, symlinks and any other special entries are skipped
+		if !file.Mode().IsRegular() || path.Base(file.Name) != name {
+			continue
+		}
+
 		zippedFile, err := file.Open()
 		if err != nil {
 			return ErrUnzipFile(err)
 		}
-		defer func() {
-			if err := zippedFile.Close(); err != nil {
-				fmt.Println(err)
-			}
-		}()
 
-		// need file traversal to place the extracted files at the right place, hence
-		// #nosec
-		extractedFilePath := path.Join(location, file.Name)
+		err = writeFileAtomic(target, zippedFile)
+		if cerr := zippedFile.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return ErrUnzipFile(err)
+		}
+		return nil
+	}
+
+	return ErrUnzipFile(fmt.Errorf("%s not found in the archive", name))
+}
 
-		if file.FileInfo().IsDir() {
-			if err := os.MkdirAll(extractedFilePath, file.Mode()); err != nil {
-				return ErrUnzipFile(err)
-			}
-		} else {
-			// we need a variable path hence,
-			// #nosec
-			outputFile, err := os.OpenFile(
-				extractedFilePath,
-				os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
-				file.Mode(),
-			)
+// spoolArchive makes the archive available for random access without keeping
+// it in memory. Regular files are read in place, any other stream is spooled
+// to a temporary file which is removed by the returned cleanup function
+func spoolArchive(content io.Reader) (*io.SectionReader, func(), error) {
+	if file, ok := content.(*os.File); ok {
+		info, err := file.Stat()
+		if err == nil && info.Mode().IsRegular() {
+			offset, err := file.Seek(0, io.SeekCurrent)
 			if err != nil {
-				return ErrUnzipFile(err)
-			}
-			defer func() {
-				if err := outputFile.Close(); err != nil {
-					fmt.Println(err)
-				}
-			}()
-
-			// Trust istio zip hence,
-			// #nosec
-			_, err = io.Copy(outputFile, zippedFile)
-			if err != nil {
-				return ErrUnzipFile(err)
+				return nil, nil, err
 			}
+			return io.NewSectionReader(file, offset, info.Size()-offset), func() {}, nil
+		}
+	}
+
+	tmp, err := ioutil.TempFile("", "istioctl-archive-*")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, content)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return io.NewSectionReader(tmp, 0, size), cleanup, nil
+}
+
+// validateEntryName rejects archive entries with absolute paths or
+// parent directory references
+func validateEntryName(name string) error {
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if normalized == "" || path.IsAbs(normalized) || filepath.VolumeName(name) != "" {
+		return fmt.Errorf("illegal file path in archive: %q", name)
+	}
+
+	for _, part := range strings.Split(normalized, "/") {
+		if part == ".." {
+			return fmt.Errorf("illegal file path in archive: %q", name)
 		}
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes the executable to target through a temporary file
+// which is renamed into place once completely written. Content larger than
+// maxBinarySize is rejected
+func writeFileAtomic(target string, content io.Reader) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+"-*")
+	if err != nil {
+		return err
+	}
+
+	discard := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+
+	// The size of the copy is capped, hence
+	// #nosec
+	written, err := io.Copy(tmp, io.LimitReader(content, maxBinarySize+1))
+	if err != nil {
+		discard()
+		return err
+	}
+	if written > maxBinarySize {
+		discard()
+		return fmt.Errorf("%s exceeds the maximum size of %d bytes", filepath.Base(target), maxBinarySize)
+	}
+
+	// Change permissions, we need the binary to be executable, hence
+	// #nosec
+	if err = tmp.Chmod(0750); err != nil {
+		discard()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), target); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
 func generatePlatformSpecificBinaryName(binName, platform string) string {
 	if platform == "windows" && !strings.HasSuffix(binName, ".exe") {
 		return binName + ".exe"
@@ -0,0 +1,354 @@
+package istio
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseChecksum(t *testing.T) {
+	valid := strings.Repeat("ab", sha256.Size)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "checksum file",
+			content: valid + "  istioctl-1.8.1-linux-amd64.tar.gz\n",
+			want:    valid,
+		},
+		{
+			name:    "upper case checksum",
+			content: strings.ToUpper(valid),
+			want:    valid,
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantErr: true,
+		},
+		{
+			name:    "truncated checksum",
+			content: valid[:10] + "  istioctl-1.8.1-linux-amd64.tar.gz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseChecksum() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksum() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyArchive(t *testing.T) {
+	content := "istioctl archive"
+	sum := sha256.Sum256([]byte(content))
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{
+			name:     "matching checksum",
+			checksum: hex.EncodeToString(sum[:]),
+			wantErr:  false,
+		},
+		{
+			name:     "checksum mismatch",
+			checksum: strings.Repeat("0", sha256.Size*2),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyArchive(strings.NewReader(content), tt.checksum)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyArchive() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			defer func() {
+				_ = got.Close()
+				_ = os.Remove(got.Name())
+			}()
+
+			read, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(read) != content {
+				t.Errorf("verifyArchive() content = %v, want %v", string(read), content)
+			}
+		})
+	}
+}
+
+type archiveEntry struct {
+	name     string
+	content  string
+	linkname string
+}
+
+func tarGzArchive(t *testing.T, entries []archiveEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Mode:     0755,
+			Size:     int64(len(entry.content)),
+			Typeflag: tar.TypeReg,
+		}
+		if entry.linkname != "" {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.linkname
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil && entry.linkname == "" {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func zipArchive(t *testing.T, entries []archiveEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name}
+		header.SetMode(0755)
+		content := entry.content
+		if entry.linkname != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			content = entry.linkname
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func Test_extractBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		binary  string
+		entries []archiveEntry
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "only the binary is extracted",
+			binary: "istioctl",
+			entries: []archiveEntry{
+				{name: "README.md", content: "readme"},
+				{name: "istioctl", content: "binary"},
+			},
+			want: "binary",
+		},
+		{
+			name:   "parent directory reference",
+			binary: "istioctl",
+			entries: []archiveEntry{
+				{name: "../../istioctl", content: "evil"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "absolute path",
+			binary: "istioctl",
+			entries: []archiveEntry{
+				{name: "/usr/local/bin/istioctl", content: "evil"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "symlinks are skipped",
+			binary: "istioctl",
+			entries: []archiveEntry{
+				{name: "istioctl", linkname: "/etc/passwd"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "binary exceeding the size limit",
+			binary: "istioctl",
+			entries: []archiveEntry{
+				{name: "istioctl", content: strings.Repeat("x", 2048)},
+			},
+			wantErr: true,
+		},
+	}
+
+	prevMaxBinarySize := maxBinarySize
+	maxBinarySize = 1024
+	defer func() {
+		maxBinarySize = prevMaxBinarySize
+	}()
+
+	extractors := map[string]func(t *testing.T, location, name string, entries []archiveEntry) error{
+		"tarxzf": func(t *testing.T, location, name string, entries []archiveEntry) error {
+			return tarxzf(filepath.Join(location, name), name, tarGzArchive(t, entries))
+		},
+		"unzip": func(t *testing.T, location, name string, entries []archiveEntry) error {
+			return unzip(filepath.Join(location, name), name, zipArchive(t, entries))
+		},
+	}
+
+	for extractor, extract := range extractors {
+		for _, tt := range tests {
+			t.Run(extractor+"/"+tt.name, func(t *testing.T) {
+				location, err := ioutil.TempDir("", "istioctl-extract")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() {
+					_ = os.RemoveAll(location)
+				}()
+
+				err = extract(t, location, tt.binary, tt.entries)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s() error = %v, wantErr %v", extractor, err, tt.wantErr)
+					return
+				}
+
+				files, err := ioutil.ReadDir(location)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.wantErr {
+					if len(files) != 0 {
+						t.Errorf("%s() left %d files behind", extractor, len(files))
+					}
+					return
+				}
+				if len(files) != 1 {
+					t.Fatalf("%s() extracted %d files, want 1", extractor, len(files))
+				}
+
+				got, err := ioutil.ReadFile(filepath.Join(location, tt.binary))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != tt.want {
+					t.Errorf("%s() = %v, want %v", extractor, string(got), tt.want)
+				}
+			})
+		}
+	}
+}
+
+func Test_spoolArchive(t *testing.T) {
+	file, err := ioutil.TempFile("", "istioctl-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err = file.WriteString("headerarchive"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.Seek(int64(len("header")), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content io.Reader
+		want    string
+	}{
+		{
+			name:    "file read in place",
+			content: file,
+			want:    "archive",
+		},
+		{
+			name:    "stream spooled to disk",
+			content: strings.NewReader("archive"),
+			want:    "archive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cleanup, err := spoolArchive(tt.content)
+			if err != nil {
+				t.Fatalf("spoolArchive() error = %v", err)
+			}
+			defer cleanup()
+
+			content, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("spoolArchive() = %v, want %v", string(content), tt.want)
+			}
+		})
+	}
+}
+
+func Test_sameRelease(t *testing.T) {
+	tests := []struct {
+		output  string
+		release string
+		want    bool
+	}{
+		{output: "1.8.1\n", release: "1.8.1", want: true},
+		{output: "1.8.1\n", release: "v1.8.1", want: true},
+		{output: "1.7.6\n", release: "1.8.1", want: false},
+		{output: "1.8.1\nsome warning\n", release: "1.8.1", want: true},
+		{output: "", release: "1.8.1", want: false},
+	}
+	for _, tt := range tests {
+		if got := sameRelease(tt.output, tt.release); got != tt.want {
+			t.Errorf("sameRelease(%q, %q) = %v, want %v", tt.output, tt.release, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package istio
 import (
 	"context"
 	"fmt"
+	"path"
 
 	"github.com/layer5io/meshery-adapter-library/adapter"
 	"github.com/layer5io/meshery-adapter-library/common"
@@ -45,11 +46,45 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 		Details:     "Operation is not supported",
 	}
 
+	// Only the operations reading their options from the custom body accept a timeout in it
+	var props map[string]string
+	if op, ok := operations[opReq.OperationName]; ok && op != nil {
+		props = op.AdditionalProperties
+	}
+	body := ""
+	switch opReq.OperationName {
+	case internalconfig.IstioOperation, internalconfig.IstioUpgradeOperation, internalconfig.IstioCanaryUpgradeOperation:
+		body = opReq.CustomBody
+	}
+	timeout, err := operationTimeout(props, body)
+	if err != nil {
+		e.Summary = "Error while parsing the operation timeout"
+		e.Details = err.Error()
+		istio.StreamErr(e, err)
+		return nil
+	}
+
+	opCtx, done := operationContext(opReq.OperationID, timeout)
+
 	switch opReq.OperationName {
 	case internalconfig.IstioOperation:
 		go func(hh *Istio, ee *adapter.Event) {
-			version := string(operations[opReq.OperationName].Versions[0])
-			stat, err := hh.installIstio(opReq.IsDeleteOperation, version, opReq.Namespace)
+			defer done()
+			version, err := requestedVersion(operations[opReq.OperationName], opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while selecting the Istio version"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			opts, err := parseInstallOptions(opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while parsing the Istio install options"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			stat, err := hh.installIstio(opCtx, opReq.OperationID, opReq.IsDeleteOperation, version, opReq.Namespace, opts)
 			if err != nil {
 				e.Summary = fmt.Sprintf("Error while %s Istio service mesh", stat)
 				e.Details = err.Error()
@@ -60,8 +95,65 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 			ee.Details = fmt.Sprintf("The Istio service mesh is now %s.", stat)
 			hh.StreamInfo(e)
 		}(istio, e)
+	case internalconfig.IstioCanaryUpgradeOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			version, err := requestedVersion(operations[opReq.OperationName], opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while selecting the Istio version"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			opts, err := parseCanaryOptions(opReq.CustomBody, version)
+			if err != nil {
+				e.Summary = "Error while parsing the Istio canary upgrade options"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			stat, err := hh.canaryUpgrade(opCtx, ee.Operationid, opReq.IsDeleteOperation, version, opts)
+			if err != nil {
+				e.Summary = fmt.Sprintf("Error while %s Istio revision %s", stat, opts.Revision)
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			ee.Summary = fmt.Sprintf("Istio revision %s %s successfully", opts.Revision, stat)
+			ee.Details = fmt.Sprintf("The Istio revision %s is now %s.", opts.Revision, stat)
+			hh.StreamInfo(e)
+		}(istio, e)
+	case internalconfig.IstioUpgradeOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			version, err := requestedVersion(operations[opReq.OperationName], opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while selecting the Istio version"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			opts, err := parseInstallOptions(opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while parsing the Istio install options"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			_, err = hh.upgradeIstio(opCtx, ee.Operationid, opReq.IsDeleteOperation, version, opts)
+			if err != nil {
+				e.Summary = fmt.Sprintf("Error while upgrading Istio service mesh to %s", version)
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			ee.Summary = fmt.Sprintf("Istio service mesh upgraded to %s successfully", version)
+			ee.Details = fmt.Sprintf("The Istio service mesh is now running version %s.", version)
+			hh.StreamInfo(e)
+		}(istio, e)
 	case common.BookInfoOperation, common.HTTPBinOperation, common.ImageHubOperation, common.EmojiVotoOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			appName := operations[opReq.OperationName].AdditionalProperties[common.ServiceName]
 			stat, err := hh.installSampleApp(opReq.Namespace, opReq.IsDeleteOperation, operations[opReq.OperationName].Templates)
 			if err != nil {
@@ -76,9 +168,10 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 		}(istio, e)
 	case common.SmiConformanceOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			name := operations[opReq.OperationName].Description
 			_, err := hh.RunSMITest(adapter.SMITestOptions{
-				Ctx:         context.TODO(),
+				Ctx:         opCtx,
 				OperationID: ee.Operationid,
 				Labels: map[string]string{
 					"istio-injection": "enabled",
@@ -99,6 +192,7 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 		}(istio, e)
 	case internalconfig.DenyAllPolicyOperation, internalconfig.StrictMTLSPolicyOperation, internalconfig.MutualMTLSPolicyOperation, internalconfig.DisableMTLSPolicyOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			stat, err := hh.applyPolicy(opReq.Namespace, opReq.IsDeleteOperation, operations[opReq.OperationName].Templates)
 			if err != nil {
 				e.Summary = fmt.Sprintf("Error while %s policy", stat)
@@ -112,6 +206,7 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 		}(istio, e)
 	case common.CustomOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			stat, err := hh.applyCustomOperation(opReq.Namespace, opReq.CustomBody, opReq.IsDeleteOperation)
 			if err != nil {
 				e.Summary = fmt.Sprintf("Error while %s custom operation", stat)
@@ -125,7 +220,15 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 		}(istio, e)
 	case internalconfig.LabelNamespace:
 		go func(hh *Istio, ee *adapter.Event) {
-			err := hh.LoadNamespaceToMesh(opReq.Namespace, opReq.IsDeleteOperation)
+			defer done()
+			revision := ""
+			var err error
+			if !opReq.IsDeleteOperation {
+				revision, err = hh.injectionRevision(opCtx, opReq.Namespace)
+			}
+			if err == nil {
+				err = hh.LoadNamespaceToMesh(opReq.Namespace, revision, opReq.IsDeleteOperation)
+			}
 			operation := "enabled"
 			if opReq.IsDeleteOperation {
 				operation = "removed"
@@ -138,15 +241,31 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 			}
 			ee.Summary = fmt.Sprintf("Label updated on %s namespace", opReq.Namespace)
 			ee.Details = fmt.Sprintf("ISTIO-INJECTION label %s on %s namespace", operation, opReq.Namespace)
+			if revision != "" {
+				ee.Details = fmt.Sprintf("Injection by revision %s %s on %s namespace", revision, operation, opReq.Namespace)
+			}
 			hh.StreamInfo(e)
 		}(istio, e)
 	case internalconfig.PrometheusAddon, internalconfig.GrafanaAddon, internalconfig.KialiAddon, internalconfig.JaegerAddon, internalconfig.ZipkinAddon:
 		go func(hh *Istio, ee *adapter.Event) {
-			svcname := operations[opReq.OperationName].AdditionalProperties[common.ServiceName]
-			patches := make([]string, 0)
-			patches = append(patches, operations[opReq.OperationName].AdditionalProperties[internalconfig.ServicePatchFile])
+			defer done()
+			opts, err := parseAddonOptions(operations[opReq.OperationName].AdditionalProperties, opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while parsing the addon options"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			manifests, err := hh.addonManifests(ee.Operationid, operations[opReq.OperationName], hh.addonVersion(opCtx, opts))
+			if err != nil {
+				e.Summary = fmt.Sprintf("Error while reading the %s manifests", opReq.OperationName)
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
 
-			_, err := hh.installAddon(opReq.Namespace, opReq.IsDeleteOperation, svcname, patches, operations[opReq.OperationName].Templates)
+			svcname := operations[opReq.OperationName].AdditionalProperties[common.ServiceName]
+			_, endpoint, err := hh.installAddon(opCtx, ee.Operationid, opReq.Namespace, opReq.IsDeleteOperation, svcname, manifests, opts)
 			operation := "install"
 			if opReq.IsDeleteOperation {
 				operation = "uninstall"
@@ -160,10 +279,30 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 			}
 			ee.Summary = fmt.Sprintf("Succesfully %sed %s", operation, opReq.OperationName)
 			ee.Details = fmt.Sprintf("Succesfully %sed %s from the %s namespace", operation, opReq.OperationName, opReq.Namespace)
+			if endpoint != "" {
+				ee.Summary = fmt.Sprintf("Succesfully %sed %s, reachable at %s", operation, opReq.OperationName, endpoint)
+				ee.Details = fmt.Sprintf("%s is exposed as %s and reachable at %s", opReq.OperationName, opts.Expose, endpoint)
+			}
+			hh.StreamInfo(e)
+		}(istio, e)
+	case internalconfig.AddonStatusOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			addons, err := hh.addonsStatus(opCtx, operations)
+			if err != nil {
+				e.Summary = "Error while reading the status of the addons"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			installed, ready := healthyAddons(addons)
+			ee.Summary = fmt.Sprintf("%d of %d installed addons ready", ready, installed)
+			ee.Details = formatAddonHealth(addons)
 			hh.StreamInfo(e)
 		}(istio, e)
 	case internalconfig.IstioVetOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			responseChan := make(chan *adapter.Event, 1)
 
 			go hh.RunVet(responseChan)
@@ -181,8 +320,44 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 
 			istio.Log.Info("Done")
 		}(istio, e)
+	case internalconfig.IstioctlCacheListOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			binaries, err := listCachedBinaries(path.Join(internalconfig.RootPath(), "bin"))
+			if err != nil {
+				e.Summary = "Error while listing the cached istioctl binaries"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			ee.Summary = fmt.Sprintf("%d istioctl binaries cached", len(binaries))
+			ee.Details = formatCachedBinaries(binaries)
+			hh.StreamInfo(e)
+		}(istio, e)
+	case internalconfig.IstioctlCachePruneOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			policy, err := cachePolicyFromProperties(operations[opReq.OperationName].AdditionalProperties, opReq.CustomBody)
+			if err != nil {
+				e.Summary = "Error while parsing the istioctl cache policy"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			removed, err := pruneBinaryCache(path.Join(internalconfig.RootPath(), "bin"), policy, "")
+			if err != nil {
+				e.Summary = "Error while pruning the cached istioctl binaries"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			ee.Summary = fmt.Sprintf("Pruned %d cached istioctl binaries", len(removed))
+			ee.Details = formatCachedBinaries(removed)
+			hh.StreamInfo(e)
+		}(istio, e)
 	case internalconfig.EnvoyFilterOperation:
 		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
 			appName := operations[opReq.OperationName].AdditionalProperties[common.ServiceName]
 			patchFile := operations[opReq.OperationName].AdditionalProperties[internalconfig.FilterPatchFile]
 			stat, err := hh.patchWithEnvoyFilter(opReq.Namespace, opReq.IsDeleteOperation, appName, operations[opReq.OperationName].Templates, patchFile)
@@ -196,13 +371,50 @@ func (istio *Istio) ApplyOperation(ctx context.Context, opReq adapter.OperationR
 			ee.Details = fmt.Sprintf("The %s application is now %s.", appName, stat)
 			hh.StreamInfo(e)
 		}(istio, e)
+	case internalconfig.CancelOperation:
+		go func(hh *Istio, ee *adapter.Event) {
+			defer done()
+			target, err := cancelTarget(opReq.CustomBody)
+			if err == nil {
+				err = cancelOperation(target)
+			}
+			if err != nil {
+				e.Summary = "Error while cancelling the operation"
+				e.Details = err.Error()
+				hh.StreamErr(e, err)
+				return
+			}
+			ee.Summary = fmt.Sprintf("Operation %s cancelled", target)
+			ee.Details = fmt.Sprintf("Cancellation of the operation %s has been requested.", target)
+			hh.StreamInfo(e)
+		}(istio, e)
 	default:
+		done()
 		istio.StreamErr(e, ErrOpInvalid)
 	}
 
 	return nil
 }
 
+// streamProgress streams an informational event for an intermediate
+// step of the operation identified by opID. Steps of the requests without
+// an operation, like the OAM ones, are logged instead
+func (istio *Istio) streamProgress(opID, summary, details string) {
+	if opID == "" {
+		if details != "" {
+			summary = fmt.Sprintf("%s: %s", summary, details)
+		}
+		istio.Log.Info(summary)
+		return
+	}
+
+	istio.StreamInfo(&adapter.Event{
+		Operationid: opID,
+		Summary:     summary,
+		Details:     details,
+	})
+}
+
 // ProcessOAM will handles the grpc invocation for handling OAM objects
 func (istio *Istio) ProcessOAM(ctx context.Context, oamReq adapter.OAMRequest) (string, error) {
 	var comps []v1alpha1.Component
@@ -224,13 +436,13 @@ func (istio *Istio) ProcessOAM(ctx context.Context, oamReq adapter.OAMRequest) (
 	// If operation is delete then first HandleConfiguration and then handle the deployment
 	if oamReq.DeleteOp {
 		// Process configuration
-		msg2, err := istio.HandleApplicationConfiguration(config, oamReq.DeleteOp)
+		msg2, err := istio.HandleApplicationConfiguration(ctx, config, oamReq.DeleteOp)
 		if err != nil {
 			return msg2, err
 		}
 
 		// Process components
-		msg1, err := istio.HandleComponents(comps, oamReq.DeleteOp)
+		msg1, err := istio.HandleComponents(ctx, comps, oamReq.DeleteOp)
 		if err != nil {
 			return msg1 + "\n" + msg2, err
 		}
@@ -239,13 +451,13 @@ func (istio *Istio) ProcessOAM(ctx context.Context, oamReq adapter.OAMRequest) (
 	}
 
 	// Process components
-	msg1, err := istio.HandleComponents(comps, oamReq.DeleteOp)
+	msg1, err := istio.HandleComponents(ctx, comps, oamReq.DeleteOp)
 	if err != nil {
 		return msg1, err
 	}
 
 	// Process configuration
-	msg2, err := istio.HandleApplicationConfiguration(config, oamReq.DeleteOp)
+	msg2, err := istio.HandleApplicationConfiguration(ctx, config, oamReq.DeleteOp)
 	if err != nil {
 		return msg1 + "\n" + msg2, err
 	}
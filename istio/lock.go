@@ -0,0 +1,96 @@
+package istio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// lockPollInterval is the interval at which a held lock file is retried
+	lockPollInterval = 500 * time.Millisecond
+
+	// lockTimeout is the time to wait for a lock file held by another process
+	lockTimeout = 15 * time.Minute
+)
+
+// releaseLocks serializes the downloads of a release within the process
+var releaseLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}{
+	locks: map[string]*sync.Mutex{},
+}
+
+// lockRelease acquires the download lock of a release, both in-process and
+// across processes sharing binPath. The returned function releases it
+func lockRelease(binPath, release string) (func(), error) {
+	releaseLocks.Lock()
+	mu, ok := releaseLocks.locks[release]
+	if !ok {
+		mu = &sync.Mutex{}
+		releaseLocks.locks[release] = mu
+	}
+	releaseLocks.Unlock()
+
+	mu.Lock()
+
+	lockFile := filepath.Join(binPath, fmt.Sprintf(".istioctl-%s.lock", release))
+	unlockFile, err := acquireFileLock(lockFile, lockTimeout)
+	if err != nil {
+		mu.Unlock()
+		return nil, ErrLockRelease(err)
+	}
+
+	return func() {
+		unlockFile()
+		mu.Unlock()
+	}, nil
+}
+
+// acquireFileLock takes the exclusive lock of the lock file, waiting for up to
+// timeout while another process holds it. The lock is held by the operating
+// system, which releases it when the holder exits, so a crashed process can't
+// leave a lock behind. The lock file itself is kept: removing it would let a
+// waiter lock a file which is no longer the lock file. The returned function
+// releases the lock
+func acquireFileLock(lockFile string, timeout time.Duration) (func(), error) {
+	// The lock file lives in the adapter's bin directory, hence
+	// #nosec
+	file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		if locked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("timed out waiting for %s", lockFile)
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+
+	// The pid of the holder helps finding who holds the lock
+	if err := file.Truncate(0); err == nil {
+		_, _ = file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
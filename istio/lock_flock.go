@@ -0,0 +1,25 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package istio
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFile takes the exclusive lock of the file without waiting, false is
+// returned if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// unlockFile releases the lock of the file
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
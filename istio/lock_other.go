@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package istio
+
+import (
+	"os"
+)
+
+// tryLockFile doesn't lock the file: istioctl isn't published for these
+// platforms, so the downloads are only serialized within the adapter
+func tryLockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile releases the lock of the file
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package istio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_lockRelease(t *testing.T) {
+	binPath, err := ioutil.TempDir("", "istioctl-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(binPath)
+	}()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holders int
+		maxSeen int
+	)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			unlock, err := lockRelease(binPath, "1.8.1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			holders++
+			if holders > maxSeen {
+				maxSeen = holders
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			holders--
+			mu.Unlock()
+
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxSeen != 1 {
+		t.Errorf("lockRelease() allowed %d concurrent holders, want 1", maxSeen)
+	}
+}
+
+func Test_acquireFileLock(t *testing.T) {
+	binPath, err := ioutil.TempDir("", "istioctl-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(binPath)
+	}()
+
+	// Left behind by a process which exited while holding the lock
+	lockFile := filepath.Join(binPath, ".istioctl-1.8.1.lock")
+	if err := ioutil.WriteFile(lockFile, []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := acquireFileLock(lockFile, time.Second)
+	if err != nil {
+		t.Fatalf("acquireFileLock() error = %v, want the unheld lock to be acquired", err)
+	}
+
+	// Held by another process
+	if _, err := acquireFileLock(lockFile, time.Second); err == nil {
+		t.Errorf("acquireFileLock() acquired a held lock")
+	}
+
+	unlock()
+	unlock, err = acquireFileLock(lockFile, time.Second)
+	if err != nil {
+		t.Fatalf("acquireFileLock() error = %v, want the released lock to be acquired", err)
+	}
+	unlock()
+}
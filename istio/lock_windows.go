@@ -0,0 +1,47 @@
+package istio
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	// errLockViolation is returned when another process holds the lock
+	errLockViolation syscall.Errno = 33
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// tryLockFile takes the exclusive lock of the file without waiting, false is
+// returned if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	overlapped := &syscall.Overlapped{}
+	r, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+	if r != 0 {
+		return true, nil
+	}
+	if err == errLockViolation {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// unlockFile releases the lock of the file
+func unlockFile(file *os.File) error {
+	overlapped := &syscall.Overlapped{}
+	r, _, err := procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-adapter-library/status"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// controlPlane is an istiod deployment running in the cluster
+type controlPlane struct {
+	Revision  string
+	Version   string
+	Namespace string
+}
+
+// CreateInstance sets up the kubernetes clients and refreshes the mesh spec
+// from the control planes running in the cluster, so that the reported state
+// survives adapter restarts
+func (istio *Istio) CreateInstance(kubeconfig []byte, contextName string, ch *chan interface{}) error {
+	if err := istio.Adapter.CreateInstance(kubeconfig, contextName, ch); err != nil {
+		return err
+	}
+
+	go istio.refreshMeshSpec(context.Background())
+
+	return nil
+}
+
+// refreshMeshSpec discovers the control planes running in the cluster and
+// persists their versions and revisions as the mesh spec
+func (istio *Istio) refreshMeshSpec(ctx context.Context) {
+	if istio.KubeClient == nil {
+		return
+	}
+
+	planes, err := istio.discoverControlPlanes(ctx)
+	if err != nil {
+		istio.Log.Warn(ErrMeshConfig(err))
+		return
+	}
+
+	meshSpec := map[string]string{}
+	if err := istio.Config.GetObject(adapter.MeshSpecKey, &meshSpec); err != nil {
+		istio.Log.Warn(ErrMeshConfig(err))
+		return
+	}
+
+	for key, value := range meshSpecFor(planes) {
+		meshSpec[key] = value
+	}
+
+	if err := istio.Config.SetObject(adapter.MeshSpecKey, meshSpec); err != nil {
+		istio.Log.Warn(ErrMeshConfig(err))
+	}
+}
+
+// discoverControlPlanes returns the istiod deployments of all namespaces
+func (istio *Istio) discoverControlPlanes(ctx context.Context) ([]controlPlane, error) {
+	deploys, err := istio.KubeClient.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: "app=istiod"})
+	if err != nil {
+		return nil, err
+	}
+
+	planes := make([]controlPlane, 0, len(deploys.Items))
+	for _, deploy := range deploys.Items {
+		planes = append(planes, controlPlaneOf(deploy))
+	}
+
+	return planes, nil
+}
+
+// findControlPlaneNamespace returns the namespace the control plane of the
+// revision runs in, the default revision is looked up if revision is empty.
+// The default namespace is assumed when no control plane can be found
+func (istio *Istio) findControlPlaneNamespace(ctx context.Context, revision string) string {
+	if istio.KubeClient == nil {
+		return defaultControlPlaneNamespace
+	}
+	if revision == "" {
+		revision = defaultRevision
+	}
+
+	planes, err := istio.discoverControlPlanes(ctx)
+	if err != nil {
+		istio.Log.Warn(ErrMeshConfig(err))
+		return defaultControlPlaneNamespace
+	}
+
+	for _, plane := range planes {
+		if plane.Revision == revision {
+			return plane.Namespace
+		}
+	}
+	if len(planes) > 0 {
+		return planes[0].Namespace
+	}
+
+	return defaultControlPlaneNamespace
+}
+
+// controlPlaneOf reads the revision and the version of an istiod deployment,
+// the version is the tag of the pilot image
+func controlPlaneOf(deploy appsv1.Deployment) controlPlane {
+	plane := controlPlane{
+		Revision:  deploy.Labels[revisionLabel],
+		Version:   status.None,
+		Namespace: deploy.Namespace,
+	}
+	if plane.Revision == "" {
+		plane.Revision = defaultRevision
+	}
+
+	for _, container := range deploy.Spec.Template.Spec.Containers {
+		if container.Name != "discovery" {
+			continue
+		}
+		if tag := imageTag(container.Image); tag != "" {
+			plane.Version = tag
+		}
+	}
+
+	return plane
+}
+
+// imageTag returns the tag of a container image reference
+func imageTag(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+
+	return image[i+1:]
+}
+
+// meshSpecFor returns the mesh spec entries for the running control planes.
+// The version is the one of the default revision, or of the first revision
+// if there is no default one, and all the revisions are listed as
+// comma separated revision=version pairs
+func meshSpecFor(planes []controlPlane) map[string]string {
+	if len(planes) == 0 {
+		return map[string]string{
+			"status":    status.NotInstalled,
+			"version":   status.None,
+			"revisions": status.None,
+		}
+	}
+
+	sorted := make([]controlPlane, len(planes))
+	copy(sorted, planes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Revision == defaultRevision) != (sorted[j].Revision == defaultRevision) {
+			return sorted[i].Revision == defaultRevision
+		}
+		return sorted[i].Revision < sorted[j].Revision
+	})
+
+	revisions := make([]string, 0, len(sorted))
+	for _, plane := range sorted {
+		revisions = append(revisions, fmt.Sprintf("%s=%s", plane.Revision, plane.Version))
+	}
+
+	return map[string]string{
+		"status":    status.Installed,
+		"version":   sorted[0].Version,
+		"revisions": strings.Join(revisions, ","),
+	}
+}
@@ -0,0 +1,102 @@
+package istio
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/status"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_imageTag(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "docker.io/istio/pilot:1.8.1", want: "1.8.1"},
+		{image: "localhost:5000/istio/pilot:1.9.0-distroless", want: "1.9.0-distroless"},
+		{image: "localhost:5000/istio/pilot", want: ""},
+		{image: "docker.io/istio/pilot:1.8.1@sha256:0123abcd", want: "1.8.1"},
+	}
+	for _, tt := range tests {
+		if got := imageTag(tt.image); got != tt.want {
+			t.Errorf("imageTag(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+func Test_controlPlaneOf(t *testing.T) {
+	deploy := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istiod-canary",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"app": "istiod", revisionLabel: "canary"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "discovery", Image: "docker.io/istio/pilot:1.9.0"},
+					},
+				},
+			},
+		},
+	}
+
+	want := controlPlane{Revision: "canary", Version: "1.9.0", Namespace: "istio-system"}
+	if got := controlPlaneOf(deploy); got != want {
+		t.Errorf("controlPlaneOf() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_meshSpecFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		planes []controlPlane
+		want   map[string]string
+	}{
+		{
+			name:   "not installed",
+			planes: nil,
+			want: map[string]string{
+				"status":    status.NotInstalled,
+				"version":   status.None,
+				"revisions": status.None,
+			},
+		},
+		{
+			name: "default and canary revisions",
+			planes: []controlPlane{
+				{Revision: "canary", Version: "1.9.0", Namespace: "istio-system"},
+				{Revision: defaultRevision, Version: "1.8.1", Namespace: "istio-system"},
+			},
+			want: map[string]string{
+				"status":    status.Installed,
+				"version":   "1.8.1",
+				"revisions": "default=1.8.1,canary=1.9.0",
+			},
+		},
+		{
+			name: "revisions only",
+			planes: []controlPlane{
+				{Revision: "1-9-0", Version: "1.9.0", Namespace: "istio-system"},
+				{Revision: "1-8-1", Version: "1.8.1", Namespace: "istio-system"},
+			},
+			want: map[string]string{
+				"status":    status.Installed,
+				"version":   "1.8.1",
+				"revisions": "1-8-1=1.8.1,1-9-0=1.9.0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := meshSpecFor(tt.planes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("meshSpecFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
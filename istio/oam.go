@@ -1,6 +1,7 @@
 package istio
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -12,12 +13,12 @@ import (
 )
 
 // HandleComponents handles the processing of OAM components
-func (istio *Istio) HandleComponents(comps []v1alpha1.Component, isDel bool) (string, error) {
+func (istio *Istio) HandleComponents(ctx context.Context, comps []v1alpha1.Component, isDel bool) (string, error) {
 	var errs []error
 	var msgs []string
 	for _, comp := range comps {
 		if comp.Spec.Type == "IstioMesh" {
-			if err := handleComponentIstioMesh(istio, comp, isDel); err != nil {
+			if err := handleComponentIstioMesh(ctx, istio, comp, isDel); err != nil {
 				errs = append(errs, err)
 			}
 
@@ -44,7 +45,7 @@ func (istio *Istio) HandleComponents(comps []v1alpha1.Component, isDel bool) (st
 			continue
 		}
 
-		if err := handleComponentIstioAddon(istio, comp, isDel); err != nil {
+		if err := handleComponentIstioAddon(ctx, istio, comp, isDel); err != nil {
 			errs = append(errs, err)
 		}
 
@@ -64,7 +65,7 @@ func (istio *Istio) HandleComponents(comps []v1alpha1.Component, isDel bool) (st
 }
 
 // HandleApplicationConfiguration handles the processing of OAM application configuration
-func (istio *Istio) HandleApplicationConfiguration(config v1alpha1.Configuration, isDel bool) (string, error) {
+func (istio *Istio) HandleApplicationConfiguration(ctx context.Context, config v1alpha1.Configuration, isDel bool) (string, error) {
 	var errs []error
 	var msgs []string
 	for _, comp := range config.Spec.Components {
@@ -80,7 +81,7 @@ func (istio *Istio) HandleApplicationConfiguration(config v1alpha1.Configuration
 
 			if trait.Name == "automaticsidecarinjection" {
 				namespaces := castSliceInterfaceToSliceString(trait.Properties["namespaces"].([]interface{}))
-				if err := handleNamespaceLabel(istio, namespaces, isDel); err != nil {
+				if err := handleNamespaceLabel(ctx, istio, namespaces, isDel); err != nil {
 					errs = append(errs, err)
 				}
 			}
@@ -101,7 +102,7 @@ func handleMTLS(istio *Istio, namespaces []string, policy string, isDel bool) er
 	for _, ns := range namespaces {
 		policyName := fmt.Sprintf("%s-mtls-policy-operation", policy)
 
-		if _, err := istio.applyPolicy(ns, isDel, config.Operations[policyName].Templates); err != nil {
+		if _, err := istio.applyPolicy(ns, isDel, config.Operations()[policyName].Templates); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -109,10 +110,18 @@ func handleMTLS(istio *Istio, namespaces []string, policy string, isDel bool) er
 	return mergeErrors(errs)
 }
 
-func handleNamespaceLabel(istio *Istio, namespaces []string, isDel bool) error {
+func handleNamespaceLabel(ctx context.Context, istio *Istio, namespaces []string, isDel bool) error {
 	var errs []error
 	for _, ns := range namespaces {
-		if err := istio.LoadNamespaceToMesh(ns, isDel); err != nil {
+		revision := ""
+		if !isDel {
+			var err error
+			if revision, err = istio.injectionRevision(ctx, ns); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := istio.LoadNamespaceToMesh(ns, revision, isDel); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -120,13 +129,18 @@ func handleNamespaceLabel(istio *Istio, namespaces []string, isDel bool) error {
 	return mergeErrors(errs)
 }
 
-func handleComponentIstioMesh(istio *Istio, comp v1alpha1.Component, isDel bool) error {
+func handleComponentIstioMesh(ctx context.Context, istio *Istio, comp v1alpha1.Component, isDel bool) error {
 	// Get the istio version from the settings
 	// we are sure that the version of istio would be present
 	// because the configuration is already validated against the schema
 	version := comp.Spec.Settings["version"].(string)
 
-	_, err := istio.installIstio(isDel, version, comp.Namespace)
+	opts, err := installOptionsFromSettings(comp.Spec.Settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = istio.installIstio(ctx, "", isDel, version, comp.Namespace, opts)
 
 	return err
 }
@@ -154,7 +168,7 @@ func handleComponentVirtualService(istio *Istio, comp v1alpha1.Component, isDel
 	return istio.applyManifest(yamlByt, isDel, comp.Namespace)
 }
 
-func handleComponentIstioAddon(istio *Istio, comp v1alpha1.Component, isDel bool) error {
+func handleComponentIstioAddon(ctx context.Context, istio *Istio, comp v1alpha1.Component, isDel bool) error {
 	var addonName string
 
 	switch comp.Spec.Type {
@@ -170,19 +184,28 @@ func handleComponentIstioAddon(istio *Istio, comp v1alpha1.Component, isDel bool
 		return nil
 	}
 
+	op := config.Operations()[addonName]
+
 	// Get the service
-	svc := config.Operations[addonName].AdditionalProperties[common.ServiceName]
+	svc := op.AdditionalProperties[common.ServiceName]
 
-	// Get the patches
-	patches := make([]string, 0)
-	patches = append(patches, config.Operations[addonName].AdditionalProperties[config.ServicePatchFile])
-	patches = append(patches, config.Operations[addonName].AdditionalProperties[config.CPPatchFile])
-	patches = append(patches, config.Operations[addonName].AdditionalProperties[config.ControlPatchFile])
+	// Get the addon options
+	settings, err := yaml.Marshal(comp.Spec.Settings)
+	if err != nil {
+		return ErrAddonInvalidConfig(err)
+	}
+	opts, err := parseAddonOptions(op.AdditionalProperties, string(settings))
+	if err != nil {
+		return err
+	}
 
-	// Get the templates
-	templates := config.Operations[addonName].Templates
+	// Get the manifests matching the installed istio release
+	manifests, err := istio.addonManifests("", op, istio.addonVersion(ctx, opts))
+	if err != nil {
+		return err
+	}
 
-	_, err := istio.installAddon(comp.Namespace, isDel, svc, patches, templates)
+	_, _, err = istio.installAddon(ctx, "", comp.Namespace, isDel, svc, manifests, opts)
 
 	return err
 }
@@ -0,0 +1,415 @@
+package istio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/layer5io/meshery-istio/internal/config"
+	"github.com/layer5io/meshkit/utils"
+	"gopkg.in/yaml.v2"
+
+	kubeerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// installerIstioctl installs the control plane by running istioctl
+	installerIstioctl = "istioctl"
+
+	// installerOperator installs the control plane by rendering the istio
+	// operator manifests and applying them through the kubernetes client
+	installerOperator = "operator"
+
+	// operatorNamespace is the namespace the istio operator is deployed in
+	operatorNamespace = "istio-operator"
+
+	// operatorHub is the registry the istio operator image is pulled from
+	operatorHub = "docker.io/istio"
+
+	// istioOperatorName is the name of the IstioOperator resource created by the adapter
+	istioOperatorName = "meshery-istio"
+
+	operatorPollInterval  = 5 * time.Second
+	operatorApplyTimeout  = time.Minute
+	operatorReadyTimeout  = 5 * time.Minute
+	operatorRemoveTimeout = 5 * time.Minute
+)
+
+var istioOperatorGVR = schema.GroupVersionResource{
+	Group:    "install.istio.io",
+	Version:  "v1alpha1",
+	Resource: "istiooperators",
+}
+
+// operatorValues are the values the operator manifest template is rendered with
+type operatorValues struct {
+	Namespace        string
+	WatchedNamespace string
+	Hub              string
+	Tag              string
+	Revision         string
+	Suffix           string
+}
+
+// manifestObject identifies a kubernetes object of a manifest
+type manifestObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+}
+
+// installWithOperator installs (or removes) the control plane through the istio
+// operator. The operator manifests and the IstioOperator resource are rendered
+// by the adapter and applied object by object, streaming the progress
+func (istio *Istio) installWithOperator(ctx context.Context, opID string, del bool, version, namespace string, opts installOptions) error {
+	if istio.KubeClient == nil {
+		return ErrNilClient
+	}
+
+	operatorManifest, err := renderOperatorManifest(operatorValues{
+		Namespace:        operatorNamespace,
+		WatchedNamespace: namespace,
+		Hub:              operatorHub,
+		Tag:              version,
+		Revision:         opts.Revision,
+		Suffix:           revisionSuffix(opts.Revision),
+	})
+	if err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	name := istioOperatorName + revisionSuffix(opts.Revision)
+	iop, err := istioOperatorManifest(name, namespace, opts)
+	if err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	client, err := dynamic.NewForConfig(&istio.RestConfig)
+	if err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	if del {
+		if err := istio.applyManifestObjects(opID, iop, true, namespace); err != nil {
+			return ErrOperatorInstall(err)
+		}
+		istio.streamProgress(opID, "Removing the Istio control plane", fmt.Sprintf("Waiting for the operator to remove %s/%s", namespace, name))
+		if err := waitForIstioOperatorRemoval(ctx, client, name, namespace); err != nil {
+			return ErrOperatorInstall(err)
+		}
+		// The CRD and the namespace are shared with the operators of the other revisions
+		if opts.Revision != "" {
+			operatorManifest = withoutKinds(operatorManifest, "CustomResourceDefinition", "Namespace")
+		}
+		if err := istio.applyManifestObjects(opID, operatorManifest, true, operatorNamespace); err != nil {
+			return ErrOperatorInstall(err)
+		}
+		return nil
+	}
+
+	if err := istio.applyManifestObjects(opID, operatorManifest, false, operatorNamespace); err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	if err := istio.applyManifestObjects(opID, namespaceManifest(namespace), false, ""); err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	// The IstioOperator kind is only served once its CRD has been established
+	err = wait.PollImmediate(operatorPollInterval, operatorApplyTimeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+		return istio.applyManifest([]byte(iop), false, namespace) == nil, nil
+	})
+	if err != nil {
+		return ErrOperatorInstall(fmt.Errorf("unable to create IstioOperator %s/%s: %s", namespace, name, err.Error()))
+	}
+	istio.streamProgress(opID, fmt.Sprintf("Applied IstioOperator %s", name), fmt.Sprintf("Waiting for the operator to reconcile %s/%s", namespace, name))
+
+	if err := istio.waitForIstioOperator(ctx, opID, client, name, namespace); err != nil {
+		return ErrOperatorInstall(err)
+	}
+
+	return nil
+}
+
+// waitForIstioOperator waits for the IstioOperator to become healthy,
+// streaming each change of its status
+func (istio *Istio) waitForIstioOperator(ctx context.Context, opID string, client dynamic.Interface, name, namespace string) error {
+	var last string
+
+	return wait.PollImmediate(operatorPollInterval, operatorReadyTimeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		iop, err := client.Resource(istioOperatorGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+
+		state, _, _ := unstructured.NestedString(iop.Object, "status", "status")
+		if state != "" && state != last {
+			last = state
+			istio.streamProgress(opID, fmt.Sprintf("IstioOperator %s is %s", name, strings.ToLower(state)), operatorComponentStatus(iop))
+		}
+
+		switch state {
+		case "HEALTHY":
+			return true, nil
+		case "ERROR":
+			return false, fmt.Errorf("reconciliation of IstioOperator %s/%s failed: %s", namespace, name, operatorComponentStatus(iop))
+		}
+
+		return false, nil
+	})
+}
+
+// waitForIstioOperatorRemoval waits until the operator has finalized the IstioOperator
+func waitForIstioOperatorRemoval(ctx context.Context, client dynamic.Interface, name, namespace string) error {
+	return wait.PollImmediate(operatorPollInterval, operatorRemoveTimeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		_, err := client.Resource(istioOperatorGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if kubeerror.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, nil
+	})
+}
+
+// operatorComponentStatus summarizes the per component status of an IstioOperator
+func operatorComponentStatus(iop *unstructured.Unstructured) string {
+	components, _, _ := unstructured.NestedMap(iop.Object, "status", "componentStatus")
+
+	var parts []string
+	for component := range components {
+		state, _, _ := unstructured.NestedString(components, component, "status")
+		msg, _, _ := unstructured.NestedString(components, component, "error")
+		if msg != "" {
+			state = fmt.Sprintf("%s (%s)", state, msg)
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", component, state))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// applyManifestObjects applies (or deletes) each object of the manifest on its
+// own and streams the progress. Objects are deleted in the reverse order
+func (istio *Istio) applyManifestObjects(opID, manifest string, del bool, namespace string) error {
+	docs := splitManifest(manifest)
+	if del {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+	}
+
+	action := "Applied"
+	if del {
+		action = "Deleted"
+	}
+
+	for _, doc := range docs {
+		obj := manifestObject{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return err
+		}
+
+		if err := istio.applyManifest([]byte(doc), del, namespace); err != nil {
+			return fmt.Errorf("%s %s: %s", obj.Kind, obj.Metadata.Name, err.Error())
+		}
+
+		istio.streamProgress(opID, fmt.Sprintf("%s %s %s", action, obj.Kind, obj.Metadata.Name), "")
+	}
+
+	return nil
+}
+
+// splitManifest splits a multi document yaml manifest into its non empty documents
+func splitManifest(manifest string) []string {
+	var (
+		docs    []string
+		current []string
+	)
+
+	flush := func() {
+		doc := strings.Join(current, "\n")
+		if strings.TrimSpace(doc) != "" {
+			docs = append(docs, doc)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(manifest, "\n") {
+		if strings.TrimRight(line, " \r") == "---" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return docs
+}
+
+// withoutKinds removes the objects of the given kinds from the manifest
+func withoutKinds(manifest string, kinds ...string) string {
+	var docs []string
+	for _, doc := range splitManifest(manifest) {
+		obj := manifestObject{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err == nil && contains(kinds, obj.Kind) {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	return strings.Join(docs, "\n---\n")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderOperatorManifest renders the operator manifest template
+func renderOperatorManifest(values operatorValues) (string, error) {
+	source := config.Operations()[config.IstioOperation].AdditionalProperties[config.OperatorManifestFile]
+
+	content, err := utils.ReadFileSource(source)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("operator").Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// namespaceManifest returns the manifest of the given namespace
+func namespaceManifest(namespace string) string {
+	return fmt.Sprintf("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: %s\n", namespace)
+}
+
+// istioOperatorManifest renders the IstioOperator resource for the install options.
+// The --set values are applied on top of the overlay like istioctl does
+func istioOperatorManifest(name, namespace string, opts installOptions) (string, error) {
+	spec := map[interface{}]interface{}{}
+
+	if strings.TrimSpace(opts.Overlay) != "" {
+		overlay := struct {
+			Spec map[interface{}]interface{} `yaml:"spec"`
+		}{}
+		if err := yaml.Unmarshal([]byte(opts.Overlay), &overlay); err != nil {
+			return "", err
+		}
+		if overlay.Spec != nil {
+			spec = overlay.Spec
+		}
+	}
+
+	spec["profile"] = opts.Profile
+	if opts.Revision != "" {
+		spec["revision"] = opts.Revision
+	}
+
+	for key, value := range opts.Set {
+		if err := setPath(spec, strings.Split(key, "."), inferValue(value)); err != nil {
+			return "", fmt.Errorf("unable to set %s: %s", key, err.Error())
+		}
+	}
+
+	iop := map[string]interface{}{
+		"apiVersion": "install.istio.io/v1alpha1",
+		"kind":       "IstioOperator",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}
+
+	out, err := yaml.Marshal(iop)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// setPath sets the value at the path in the nested map, creating the
+// intermediate maps as required
+func setPath(tree map[interface{}]interface{}, path []string, value interface{}) error {
+	if len(path) == 1 {
+		tree[path[0]] = value
+		return nil
+	}
+
+	next, ok := tree[path[0]]
+	if !ok || next == nil {
+		next = map[interface{}]interface{}{}
+		tree[path[0]] = next
+	}
+
+	subtree, ok := next.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not an object", path[0])
+	}
+
+	return setPath(subtree, path[1:], value)
+}
+
+// inferValue converts the --set values into numbers and booleans like istioctl
+// does, numbers are tried first so that "1" and "0" stay integers. Escaped
+// commas are unescaped in the remaining strings
+func inferValue(value string) interface{} {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+
+	return strings.ReplaceAll(value, `\,`, ",")
+}
+
+// revisionSuffix returns the suffix used for the names of revisioned resources
+func revisionSuffix(revision string) string {
+	if revision == "" {
+		return ""
+	}
+
+	return "-" + revision
+}
@@ -0,0 +1,140 @@
+package istio
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func Test_istioOperatorManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    installOptions
+		want    map[interface{}]interface{}
+		wantErr bool
+	}{
+		{
+			name: "profile and revision",
+			opts: installOptions{Profile: "minimal", Revision: "canary"},
+			want: map[interface{}]interface{}{
+				"profile":  "minimal",
+				"revision": "canary",
+			},
+		},
+		{
+			name: "values on top of the overlay",
+			opts: installOptions{
+				Profile: "demo",
+				Set: map[string]string{
+					"meshConfig.accessLogFile":         "/dev/stdout",
+					"values.global.proxy.privileged":   "true",
+					"values.pilot.autoscaleMax":        "3",
+					"meshConfig.defaultConfig.holdApp": "false",
+				},
+				Overlay: "apiVersion: install.istio.io/v1alpha1\nkind: IstioOperator\nspec:\n  profile: default\n  meshConfig:\n    enableTracing: true\n    accessLogFile: /tmp/access.log\n",
+			},
+			want: map[interface{}]interface{}{
+				"profile": "demo",
+				"meshConfig": map[interface{}]interface{}{
+					"enableTracing": true,
+					"accessLogFile": "/dev/stdout",
+					"defaultConfig": map[interface{}]interface{}{
+						"holdApp": false,
+					},
+				},
+				"values": map[interface{}]interface{}{
+					"global": map[interface{}]interface{}{
+						"proxy": map[interface{}]interface{}{
+							"privileged": true,
+						},
+					},
+					"pilot": map[interface{}]interface{}{
+						"autoscaleMax": 3,
+					},
+				},
+			},
+		},
+		{
+			name: "value below a scalar",
+			opts: installOptions{
+				Profile: "demo",
+				Set:     map[string]string{"meshConfig.enableTracing.sampling": "10"},
+				Overlay: "apiVersion: install.istio.io/v1alpha1\nkind: IstioOperator\nspec:\n  meshConfig:\n    enableTracing: true\n",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := istioOperatorManifest("meshery-istio", "istio-system", tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("istioOperatorManifest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			iop := struct {
+				Kind     string `yaml:"kind"`
+				Metadata struct {
+					Name      string `yaml:"name"`
+					Namespace string `yaml:"namespace"`
+				} `yaml:"metadata"`
+				Spec map[interface{}]interface{} `yaml:"spec"`
+			}{}
+			if err := yaml.Unmarshal([]byte(got), &iop); err != nil {
+				t.Fatal(err)
+			}
+			if iop.Kind != "IstioOperator" || iop.Metadata.Name != "meshery-istio" || iop.Metadata.Namespace != "istio-system" {
+				t.Errorf("istioOperatorManifest() = %v, want the meshery-istio IstioOperator in istio-system", got)
+			}
+			if !reflect.DeepEqual(iop.Spec, tt.want) {
+				t.Errorf("istioOperatorManifest() spec = %v, want %v", iop.Spec, tt.want)
+			}
+		})
+	}
+}
+
+func Test_inferValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{value: "1", want: 1},
+		{value: "0", want: 0},
+		{value: "3", want: 3},
+		{value: "0.5", want: 0.5},
+		{value: "true", want: true},
+		{value: "f", want: false},
+		{value: "/dev/stdout", want: "/dev/stdout"},
+		{value: `a\,b`, want: "a,b"},
+	}
+	for _, tt := range tests {
+		if got := inferValue(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("inferValue(%q) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func Test_splitManifest(t *testing.T) {
+	manifest := "---\napiVersion: v1\nkind: Namespace\n---\n\n---   \napiVersion: v1\nkind: ServiceAccount\n"
+
+	want := []string{
+		"apiVersion: v1\nkind: Namespace",
+		"apiVersion: v1\nkind: ServiceAccount\n",
+	}
+	if got := splitManifest(manifest); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitManifest() = %q, want %q", got, want)
+	}
+}
+
+func Test_withoutKinds(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: Namespace\n---\napiVersion: v1\nkind: ServiceAccount\n---\napiVersion: apps/v1\nkind: Deployment\n"
+
+	want := []string{"apiVersion: v1\nkind: ServiceAccount", "apiVersion: apps/v1\nkind: Deployment\n"}
+	if got := splitManifest(withoutKinds(manifest, "Namespace", "CustomResourceDefinition")); !reflect.DeepEqual(got, want) {
+		t.Errorf("withoutKinds() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package istio
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// defaultProfile is the istio configuration profile which gets installed
+	// when the operation request doesn't ask for a specific one
+	defaultProfile = "demo"
+
+	// defaultControlPlaneNamespace is the namespace the control plane is
+	// installed in unless the operation asks for another one
+	defaultControlPlaneNamespace = "istio-system"
+
+	// istioNamespaceKey is the value holding the namespace of the control plane
+	istioNamespaceKey = "values.global.istioNamespace"
+)
+
+var (
+	// supportedProfiles are the configuration profiles shipped with istioctl
+	supportedProfiles = []string{"default", "demo", "minimal", "preview", "empty", "remote"}
+
+	// setKeyPattern matches the paths accepted by "istioctl install --set"
+	setKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_\-\[\]\.]+$`)
+
+	// revisionPattern matches valid control plane revision names
+	revisionPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9\-]{0,61}[a-z0-9])?$`)
+)
+
+// installOptions holds the user supplied settings for the istio operation.
+// They are read either from the custom body of the operation request or
+// from the settings of an IstioMesh OAM component
+type installOptions struct {
+	// Profile is the istio configuration profile to install
+	Profile string `yaml:"profile,omitempty"`
+	// Set holds the values passed on to istioctl using --set
+	Set map[string]string `yaml:"set,omitempty"`
+	// Overlay is an IstioOperator manifest applied on top of the profile
+	Overlay string `yaml:"overlay,omitempty"`
+	// Revision installs the control plane as the given revision
+	Revision string `yaml:"revision,omitempty"`
+	// Installer selects how the control plane gets installed, either by
+	// running istioctl or by applying the istio operator manifests
+	Installer string `yaml:"installer,omitempty"`
+	// Namespace is the namespace to install the control plane in
+	Namespace string `yaml:"namespace,omitempty"`
+	// ReadinessTimeout is the time the control plane gets to become ready
+	// after the install, "0s" skips the readiness verification
+	ReadinessTimeout string `yaml:"readinessTimeout,omitempty"`
+}
+
+// istioOperatorHeader is used to identify the overlay manifest
+type istioOperatorHeader struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// parseInstallOptions reads the install options from the given yaml (or json)
+// document, an empty document results in the default options
+func parseInstallOptions(body string) (installOptions, error) {
+	opts := installOptions{}
+	if strings.TrimSpace(body) != "" {
+		if err := yaml.Unmarshal([]byte(body), &opts); err != nil {
+			return opts, ErrInvalidInstallOptions(err)
+		}
+	}
+
+	if opts.Profile == "" {
+		opts.Profile = defaultProfile
+	}
+	if opts.Installer == "" {
+		opts.Installer = installerIstioctl
+	}
+
+	return opts, opts.validate()
+}
+
+// installOptionsFromSettings converts the settings of an OAM component
+// into install options
+func installOptionsFromSettings(settings map[string]interface{}) (installOptions, error) {
+	byt, err := yaml.Marshal(settings)
+	if err != nil {
+		return installOptions{}, ErrInvalidInstallOptions(err)
+	}
+
+	return parseInstallOptions(string(byt))
+}
+
+// validate makes sure that the options can be safely passed on to istioctl
+func (o installOptions) validate() error {
+	if !isSupportedProfile(o.Profile) {
+		return ErrInvalidInstallOptions(fmt.Errorf("unsupported profile %q, supported profiles are: %s", o.Profile, strings.Join(supportedProfiles, ", ")))
+	}
+
+	if o.Installer != installerIstioctl && o.Installer != installerOperator {
+		return ErrInvalidInstallOptions(fmt.Errorf("unsupported installer %q, supported installers are: %s, %s", o.Installer, installerIstioctl, installerOperator))
+	}
+
+	if o.ReadinessTimeout != "" {
+		if _, err := time.ParseDuration(o.ReadinessTimeout); err != nil {
+			return ErrInvalidInstallOptions(fmt.Errorf("invalid readiness timeout: %s", err.Error()))
+		}
+	}
+
+	if o.Revision != "" && !revisionPattern.MatchString(o.Revision) {
+		return ErrInvalidInstallOptions(fmt.Errorf("invalid revision %q", o.Revision))
+	}
+
+	if o.Namespace != "" && !revisionPattern.MatchString(o.Namespace) {
+		return ErrInvalidInstallOptions(fmt.Errorf("invalid namespace %q", o.Namespace))
+	}
+	if ns, ok := o.Set[istioNamespaceKey]; ok && o.Namespace != "" && ns != o.Namespace {
+		return ErrInvalidInstallOptions(fmt.Errorf("namespace %q conflicts with %s=%s", o.Namespace, istioNamespaceKey, ns))
+	}
+
+	for key, value := range o.Set {
+		if !setKeyPattern.MatchString(key) {
+			return ErrInvalidInstallOptions(fmt.Errorf("invalid --set key %q", key))
+		}
+		if o.Installer == installerOperator && strings.ContainsAny(key, "[]") {
+			return ErrInvalidInstallOptions(fmt.Errorf("list indices in --set key %q are not supported by the operator installer", key))
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return ErrInvalidInstallOptions(fmt.Errorf("invalid --set value for key %q", key))
+		}
+	}
+
+	if strings.TrimSpace(o.Overlay) == "" {
+		return nil
+	}
+
+	header := istioOperatorHeader{}
+	if err := yaml.Unmarshal([]byte(o.Overlay), &header); err != nil {
+		return ErrInvalidInstallOptions(fmt.Errorf("invalid overlay: %s", err.Error()))
+	}
+	if header.Kind != "IstioOperator" || !strings.HasPrefix(header.APIVersion, "install.istio.io/") {
+		return ErrInvalidInstallOptions(fmt.Errorf("overlay must be an install.istio.io IstioOperator, found %q %q", header.APIVersion, header.Kind))
+	}
+
+	return nil
+}
+
+// args returns the istioctl flags for the options, overlayFile is the
+// location where the overlay has been written to
+func (o installOptions) args(overlayFile string) []string {
+	args := []string{"--set", "profile=" + o.Profile}
+	if o.Revision != "" {
+		args = append(args, "--set", "revision="+o.Revision)
+	}
+
+	keys := make([]string, 0, len(o.Set))
+	for key := range o.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", key, o.Set[key]))
+	}
+
+	if overlayFile != "" {
+		args = append(args, "-f", overlayFile)
+	}
+
+	return args
+}
+
+// controlPlaneNamespace returns the namespace to install the control plane in,
+// the one of the options if any, else the requested one. Meshery requests the
+// default namespace unless told otherwise, which is mapped to istio-system
+func (o installOptions) controlPlaneNamespace(requested string) string {
+	if o.Namespace != "" {
+		return o.Namespace
+	}
+	if ns := o.Set[istioNamespaceKey]; ns != "" {
+		return ns
+	}
+	if requested != "" && requested != "default" {
+		return requested
+	}
+
+	return defaultControlPlaneNamespace
+}
+
+// withNamespace returns the options installing the control plane in the namespace
+func (o installOptions) withNamespace(namespace string) installOptions {
+	set := make(map[string]string, len(o.Set)+1)
+	for key, value := range o.Set {
+		set[key] = value
+	}
+	set[istioNamespaceKey] = namespace
+
+	o.Set = set
+	o.Namespace = namespace
+	return o
+}
+
+func isSupportedProfile(profile string) bool {
+	for _, p := range supportedProfiles {
+		if p == profile {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,163 @@
+package istio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseInstallOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "empty body",
+			body: "",
+			want: []string{"--set", "profile=demo"},
+		},
+		{
+			name: "profile and values",
+			body: `{"profile": "minimal", "set": {"values.global.proxy.privileged": "true", "meshConfig.accessLogFile": "/dev/stdout"}}`,
+			want: []string{
+				"--set", "profile=minimal",
+				"--set", "meshConfig.accessLogFile=/dev/stdout",
+				"--set", "values.global.proxy.privileged=true",
+			},
+		},
+		{
+			name:    "unsupported profile",
+			body:    "profile: production",
+			wantErr: true,
+		},
+		{
+			name:    "invalid set key",
+			body:    `{"set": {"values.global; rm -rf": "true"}}`,
+			wantErr: true,
+		},
+		{
+			name: "revision",
+			body: "revision: 1-8-1",
+			want: []string{"--set", "profile=demo", "--set", "revision=1-8-1"},
+		},
+		{
+			name:    "invalid revision",
+			body:    "revision: 1.8.1",
+			wantErr: true,
+		},
+		{
+			name:    "overlay of the wrong kind",
+			body:    "overlay: |\n  apiVersion: v1\n  kind: ConfigMap\n",
+			wantErr: true,
+		},
+		{
+			name: "operator installer",
+			body: `{"installer": "operator", "set": {"values.global.proxy.privileged": "true"}}`,
+			want: []string{"--set", "profile=demo", "--set", "values.global.proxy.privileged=true"},
+		},
+		{
+			name:    "unsupported installer",
+			body:    "installer: helm",
+			wantErr: true,
+		},
+		{
+			name:    "list index with the operator installer",
+			body:    `{"installer": "operator", "set": {"components.ingressGateways[0].enabled": "true"}}`,
+			wantErr: true,
+		},
+		{
+			name: "namespace",
+			body: "namespace: mesh-system",
+			want: []string{"--set", "profile=demo"},
+		},
+		{
+			name:    "invalid namespace",
+			body:    "namespace: Mesh_System",
+			wantErr: true,
+		},
+		{
+			name:    "conflicting namespaces",
+			body:    `{"namespace": "mesh-system", "set": {"values.global.istioNamespace": "istio-system"}}`,
+			wantErr: true,
+		},
+		{
+			name: "istio operator overlay",
+			body: "profile: default\noverlay: |\n  apiVersion: install.istio.io/v1alpha1\n  kind: IstioOperator\n  spec:\n    meshConfig:\n      enableTracing: true\n",
+			want: []string{"--set", "profile=default"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseInstallOptions(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseInstallOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if args := got.args(""); !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("installOptions.args() = %v, want %v", args, tt.want)
+			}
+		})
+	}
+}
+
+func Test_installOptions_controlPlaneNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      installOptions
+		requested string
+		want      string
+	}{
+		{
+			name:      "namespace option",
+			opts:      installOptions{Namespace: "mesh-system"},
+			requested: "istio",
+			want:      "mesh-system",
+		},
+		{
+			name:      "istio namespace value",
+			opts:      installOptions{Set: map[string]string{istioNamespaceKey: "mesh-system"}},
+			requested: "istio",
+			want:      "mesh-system",
+		},
+		{
+			name:      "requested namespace",
+			opts:      installOptions{},
+			requested: "istio",
+			want:      "istio",
+		},
+		{
+			name:      "default namespace",
+			opts:      installOptions{},
+			requested: "default",
+			want:      defaultControlPlaneNamespace,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.controlPlaneNamespace(tt.requested); got != tt.want {
+				t.Errorf("installOptions.controlPlaneNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_installOptions_withNamespace(t *testing.T) {
+	opts := installOptions{Profile: "demo", Set: map[string]string{"meshConfig.accessLogFile": "/dev/stdout"}}
+
+	got := opts.withNamespace("mesh-system")
+	want := []string{
+		"--set", "profile=demo",
+		"--set", "meshConfig.accessLogFile=/dev/stdout",
+		"--set", "values.global.istioNamespace=mesh-system",
+	}
+	if args := got.args(""); !reflect.DeepEqual(args, want) {
+		t.Errorf("installOptions.withNamespace().args() = %v, want %v", args, want)
+	}
+	if _, ok := opts.Set[istioNamespaceKey]; ok {
+		t.Errorf("installOptions.withNamespace() modified the original options")
+	}
+}
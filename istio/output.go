@@ -0,0 +1,86 @@
+package istio
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ansiEscape matches the terminal escape sequences istioctl decorates its output with
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// maxLineLength is the length past which the pending output of a lineWriter
+// is emitted as a line of its own, so that output without newlines isn't
+// buffered without bound
+const maxLineLength = 64 << 10
+
+// lineWriter is an io.Writer calling emit for every complete line written
+// to it. Both "\n" and "\r" terminate a line, blank lines are dropped and
+// lines longer than maxLineLength are split
+type lineWriter struct {
+	mu      sync.Mutex
+	pending bytes.Buffer
+	emit    func(line string)
+}
+
+func newLineWriter(emit func(line string)) *lineWriter {
+	return &lineWriter{emit: emit}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, b := range p {
+		if b != '\n' && b != '\r' {
+			w.pending.WriteByte(b)
+			if w.pending.Len() >= maxLineLength {
+				w.flushLine()
+			}
+			continue
+		}
+		w.flushLine()
+	}
+
+	return len(p), nil
+}
+
+// Flush emits the trailing output which isn't terminated by a newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.flushLine()
+}
+
+func (w *lineWriter) flushLine() {
+	line := strings.TrimSpace(ansiEscape.ReplaceAllString(w.pending.String(), ""))
+	w.pending.Reset()
+
+	if line != "" {
+		w.emit(line)
+	}
+}
+
+// istioCtlOutput returns a writer streaming each line of the output of
+// "istioctl <command>" as an event of the operation identified by opID
+func (istio *Istio) istioCtlOutput(opID, command string) *lineWriter {
+	return newLineWriter(func(line string) {
+		istio.streamProgress(opID, line, "istioctl "+command)
+	})
+}
+
+// subcommand returns the istioctl subcommand of the arguments, for example
+// "x uninstall" for "x uninstall --purge -y"
+func subcommand(args []string) string {
+	var cmd []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		cmd = append(cmd, arg)
+	}
+
+	return strings.Join(cmd, " ")
+}
@@ -0,0 +1,73 @@
+package istio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_lineWriter(t *testing.T) {
+	var lines []string
+	w := newLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	writes := []string{
+		"\x1b[32m✔\x1b[0m Istio core installed\n",
+		"- Processing resources for Istiod.\r",
+		"✔ Istiod ins",
+		"talled\n\n   \n",
+		"- Pruning removed resources",
+	}
+	for _, s := range writes {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"✔ Istio core installed", "- Processing resources for Istiod.", "✔ Istiod installed"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lineWriter emitted %q, want %q", lines, want)
+	}
+
+	w.Flush()
+	want = append(want, "- Pruning removed resources")
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lineWriter.Flush() emitted %q, want %q", lines, want)
+	}
+}
+
+func Test_lineWriter_longLine(t *testing.T) {
+	var lines []string
+	w := newLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	if _, err := w.Write([]byte(strings.Repeat("x", maxLineLength+10))); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || len(lines[0]) != maxLineLength {
+		t.Fatalf("lineWriter emitted %d lines, want one line of %d bytes", len(lines), maxLineLength)
+	}
+
+	w.Flush()
+	if len(lines) != 2 || lines[1] != strings.Repeat("x", 10) {
+		t.Errorf("lineWriter.Flush() emitted %d lines, want the remaining 10 bytes", len(lines))
+	}
+}
+
+func Test_subcommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"install", "--set", "profile=demo", "-y"}, want: "install"},
+		{args: []string{"x", "uninstall", "--purge", "-y"}, want: "x uninstall"},
+		{args: []string{"-y"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := subcommand(tt.args); got != tt.want {
+			t.Errorf("subcommand(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package istio
+
+import (
+	"fmt"
+
+	"github.com/layer5io/meshery-istio/internal/config"
+)
+
+// istioctlAssets lists the names of the istioctl archives which can run on a
+// platform and architecture, in order of preference. The names changed over
+// the releases, older ones only published a single build per platform, and
+// the amd64 builds are a fallback for Apple Silicon through Rosetta. The first
+// name is the one used when the assets of the release are unknown
+var istioctlAssets = map[string]map[string][]string{
+	"linux": {
+		"amd64": {"istioctl-%s-linux-amd64.tar.gz", "istioctl-%s-linux.tar.gz"},
+		"arm64": {"istioctl-%s-linux-arm64.tar.gz"},
+		"arm":   {"istioctl-%s-linux-armv7.tar.gz"},
+	},
+	"darwin": {
+		"amd64": {"istioctl-%s-osx.tar.gz", "istioctl-%s-osx-amd64.tar.gz"},
+		"arm64": {"istioctl-%s-osx-arm64.tar.gz", "istioctl-%s-osx.tar.gz", "istioctl-%s-osx-amd64.tar.gz"},
+	},
+	"windows": {
+		"amd64": {"istioctl-%s-win.zip", "istioctl-%s-win-amd64.zip"},
+	},
+}
+
+// istioctlAsset returns the name of the istioctl archive of the release for
+// the platform and architecture, picking the preferred build among those the
+// release advertises
+func istioctlAsset(platform, arch, release string) (string, error) {
+	var assets []*config.Asset
+	if metadata, err := config.GetRelease(release); err == nil {
+		assets = metadata.Assets
+	}
+
+	return resolveAsset(platform, arch, release, assets)
+}
+
+// resolveAsset picks the istioctl archive for the platform and architecture
+// among the advertised assets of the release
+func resolveAsset(platform, arch, release string, assets []*config.Asset) (string, error) {
+	candidates := istioctlAssets[platform][arch]
+	if len(candidates) == 0 {
+		return "", ErrUnsupportedPlatform(platform, arch, release)
+	}
+
+	if len(assets) == 0 {
+		return fmt.Sprintf(candidates[0], release), nil
+	}
+
+	advertised := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		if asset != nil {
+			advertised[asset.Name] = true
+		}
+	}
+
+	for _, candidate := range candidates {
+		if name := fmt.Sprintf(candidate, release); advertised[name] {
+			return name, nil
+		}
+	}
+
+	return "", ErrUnsupportedPlatform(platform, arch, release)
+}
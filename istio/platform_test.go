@@ -0,0 +1,116 @@
+package istio
+
+import (
+	"testing"
+
+	"github.com/layer5io/meshery-istio/internal/config"
+)
+
+func assetsOf(names ...string) []*config.Asset {
+	assets := make([]*config.Asset, 0, len(names))
+	for _, name := range names {
+		assets = append(assets, &config.Asset{Name: name})
+	}
+
+	return assets
+}
+
+func Test_resolveAsset(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		arch     string
+		release  string
+		assets   []*config.Asset
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "linux amd64 without metadata",
+			platform: "linux",
+			arch:     "amd64",
+			release:  "1.8.1",
+			want:     "istioctl-1.8.1-linux-amd64.tar.gz",
+		},
+		{
+			name:     "linux armv7",
+			platform: "linux",
+			arch:     "arm",
+			release:  "1.8.1",
+			assets:   assetsOf("istioctl-1.8.1-linux-amd64.tar.gz", "istioctl-1.8.1-linux-armv7.tar.gz"),
+			want:     "istioctl-1.8.1-linux-armv7.tar.gz",
+		},
+		{
+			name:     "linux amd64 before per architecture builds",
+			platform: "linux",
+			arch:     "amd64",
+			release:  "1.5.0",
+			assets:   assetsOf("istioctl-1.5.0-linux.tar.gz", "istioctl-1.5.0-osx.tar.gz"),
+			want:     "istioctl-1.5.0-linux.tar.gz",
+		},
+		{
+			name:     "linux arm64 before per architecture builds",
+			platform: "linux",
+			arch:     "arm64",
+			release:  "1.5.0",
+			assets:   assetsOf("istioctl-1.5.0-linux.tar.gz", "istioctl-1.5.0-osx.tar.gz"),
+			wantErr:  true,
+		},
+		{
+			name:     "apple silicon",
+			platform: "darwin",
+			arch:     "arm64",
+			release:  "1.10.0",
+			assets:   assetsOf("istioctl-1.10.0-osx.tar.gz", "istioctl-1.10.0-osx-arm64.tar.gz"),
+			want:     "istioctl-1.10.0-osx-arm64.tar.gz",
+		},
+		{
+			name:     "apple silicon falls back to the amd64 build",
+			platform: "darwin",
+			arch:     "arm64",
+			release:  "1.8.1",
+			assets:   assetsOf("istioctl-1.8.1-osx.tar.gz", "istioctl-1.8.1-win.zip"),
+			want:     "istioctl-1.8.1-osx.tar.gz",
+		},
+		{
+			name:     "darwin amd64 without metadata",
+			platform: "darwin",
+			arch:     "amd64",
+			release:  "1.8.1",
+			want:     "istioctl-1.8.1-osx.tar.gz",
+		},
+		{
+			name:     "windows",
+			platform: "windows",
+			arch:     "amd64",
+			release:  "1.8.1",
+			assets:   assetsOf("istioctl-1.8.1-win.zip"),
+			want:     "istioctl-1.8.1-win.zip",
+		},
+		{
+			name:     "unknown platform",
+			platform: "plan9",
+			arch:     "amd64",
+			release:  "1.8.1",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown architecture",
+			platform: "linux",
+			arch:     "s390x",
+			release:  "1.8.1",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAsset(tt.platform, tt.arch, tt.release, tt.assets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveAsset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveAsset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"github.com/layer5io/meshery-istio/internal/config"
+	"gopkg.in/yaml.v2"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// defaultReadinessTimeout is the time the control plane gets to become
+	// ready unless the operation configures one
+	defaultReadinessTimeout = 5 * time.Minute
+
+	readinessPollInterval = 5 * time.Second
+)
+
+// profileComponents are the components enabled by the configuration profiles,
+// the profiles missing from the map install neither istiod nor gateways
+var profileComponents = map[string]struct {
+	istiod, ingress, egress bool
+}{
+	"default": {istiod: true, ingress: true},
+	"demo":    {istiod: true, ingress: true, egress: true},
+	"preview": {istiod: true, ingress: true},
+	"minimal": {istiod: true},
+}
+
+// gatewayComponent is a gateway of the components of an IstioOperator
+type gatewayComponent struct {
+	Name    string `yaml:"name"`
+	Enabled *bool  `yaml:"enabled"`
+}
+
+// overlayComponents are the components configured by an IstioOperator overlay
+type overlayComponents struct {
+	Spec struct {
+		Components struct {
+			Pilot struct {
+				Enabled *bool `yaml:"enabled"`
+			} `yaml:"pilot"`
+			IngressGateways []gatewayComponent `yaml:"ingressGateways"`
+			EgressGateways  []gatewayComponent `yaml:"egressGateways"`
+		} `yaml:"components"`
+	} `yaml:"spec"`
+}
+
+// setGatewayPattern matches the --set keys configuring a gateway
+var setGatewayPattern = regexp.MustCompile(`^components\.(ingressGateways|egressGateways)\[(\d+)\]\.(name|enabled)$`)
+
+// expectedControlPlane holds the components the install options deploy
+type expectedControlPlane struct {
+	Istiod   bool
+	Gateways []string
+}
+
+// componentStatus is the readiness of a control plane component
+type componentStatus struct {
+	Name    string
+	Ready   bool
+	Message string
+}
+
+// readinessTimeout returns the time the control plane gets to become ready,
+// zero disables the readiness verification
+func (istio *Istio) readinessTimeout(opts installOptions) (time.Duration, error) {
+	timeout := opts.ReadinessTimeout
+	if timeout == "" {
+		operations := make(adapter.Operations)
+		if err := istio.Config.GetObject(adapter.OperationsKey, &operations); err == nil {
+			if op, ok := operations[config.IstioOperation]; ok && op != nil {
+				timeout = op.AdditionalProperties[config.ReadinessTimeout]
+			}
+		}
+	}
+
+	if timeout == "" {
+		return defaultReadinessTimeout, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, ErrInvalidTimeout(err)
+	}
+
+	return d, nil
+}
+
+// verifyInstall waits for the control plane components to become ready and,
+// for istioctl installs, checks the installation with "istioctl verify-install".
+// The failure lists the state of every component
+func (istio *Istio) verifyInstall(ctx context.Context, opID, version, namespace string, opts installOptions) error {
+	timeout, err := istio.readinessTimeout(opts)
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return nil
+	}
+
+	if istio.KubeClient == nil {
+		return ErrNilClient
+	}
+
+	expected := expectedComponents(opts)
+	if !expected.Istiod && len(expected.Gateways) == 0 {
+		istio.streamProgress(opID, "Skipped the readiness verification", fmt.Sprintf("The %s profile doesn't deploy istiod nor gateways", opts.Profile))
+		return nil
+	}
+
+	istio.streamProgress(opID, "Waiting for the control plane", fmt.Sprintf("Waiting up to %s for the control plane components in %s to become ready", timeout, namespace))
+
+	reported := map[string]bool{}
+	var components []componentStatus
+
+	err = wait.PollImmediate(readinessPollInterval, timeout, func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, contextError(ctx)
+		}
+
+		current, err := istio.controlPlaneStatus(ctx, namespace, opts.Revision, expected)
+		if err != nil {
+			istio.Log.Warn(ErrControlPlaneNotReady(err))
+			return false, nil
+		}
+		components = current
+
+		ready := true
+		for _, component := range components {
+			if !component.Ready {
+				ready = false
+				continue
+			}
+			if !reported[component.Name] {
+				reported[component.Name] = true
+				istio.streamProgress(opID, fmt.Sprintf("%s is ready", component.Name), component.Message)
+			}
+		}
+
+		return ready, nil
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return ErrControlPlaneNotReady(fmt.Errorf("%s\n%s", err.Error(), formatComponentStatus(istio.diagnose(ctx, namespace, components))))
+	}
+
+	// verify-install checks istiod and its webhooks
+	if opts.Installer != installerIstioctl || !expected.Istiod {
+		return nil
+	}
+
+	args := []string{"verify-install", "--istioNamespace", namespace}
+	if opts.Revision != "" {
+		args = append(args, "--revision", opts.Revision)
+	}
+	if err := istio.execIstioCtl(ctx, opID, version, args...); err != nil {
+		return ErrControlPlaneNotReady(err)
+	}
+
+	return nil
+}
+
+// controlPlaneStatus returns the readiness of the expected components of the
+// control plane in the namespace: istiod with its webhook configurations and
+// the gateways
+func (istio *Istio) controlPlaneStatus(ctx context.Context, namespace, revision string, expected expectedControlPlane) ([]componentStatus, error) {
+	deploys := istio.KubeClient.AppsV1().Deployments(namespace)
+
+	gateways, err := deploys.List(ctx, metav1.ListOptions{LabelSelector: "istio in (ingressgateway,egressgateway)"})
+	if err != nil {
+		return nil, err
+	}
+	components := deploymentComponents("", gateways.Items)
+	for _, name := range expected.Gateways {
+		if !hasComponent(components, name) {
+			components = append(components, componentStatus{Name: name, Message: "deployment not found"})
+		}
+	}
+
+	if !expected.Istiod {
+		return components, nil
+	}
+
+	istiodSelector := "app=istiod"
+	injectorSelector := "app=sidecar-injector"
+	if revision != "" {
+		istiodSelector += fmt.Sprintf(",%s=%s", revisionLabel, revision)
+		injectorSelector += fmt.Sprintf(",%s=%s", revisionLabel, revision)
+	}
+
+	istiods, err := deploys.List(ctx, metav1.ListOptions{LabelSelector: istiodSelector})
+	if err != nil {
+		return nil, err
+	}
+	components = append(deploymentComponents("istiod", istiods.Items), components...)
+
+	injectors, err := istio.KubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{LabelSelector: injectorSelector})
+	if err != nil {
+		return nil, err
+	}
+	if len(injectors.Items) == 0 {
+		components = append(components, componentStatus{Name: "sidecar injector webhook", Message: "webhook configuration not found"})
+	}
+	for _, webhook := range injectors.Items {
+		patched := true
+		for _, hook := range webhook.Webhooks {
+			patched = patched && len(hook.ClientConfig.CABundle) > 0
+		}
+		components = append(components, webhookComponent(webhook.Name, patched))
+	}
+
+	// Only the default revision registers the validation webhook
+	if revision == "" {
+		validators, err := istio.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{LabelSelector: "app=istiod"})
+		if err != nil {
+			return nil, err
+		}
+		if len(validators.Items) == 0 {
+			components = append(components, componentStatus{Name: "validation webhook", Message: "webhook configuration not found"})
+		}
+		for _, webhook := range validators.Items {
+			patched := true
+			for _, hook := range webhook.Webhooks {
+				patched = patched && len(hook.ClientConfig.CABundle) > 0
+			}
+			components = append(components, webhookComponent(webhook.Name, patched))
+		}
+	}
+
+	return components, nil
+}
+
+// diagnose adds the reasons keeping the pods of the deployments which
+// aren't ready from running to the component messages
+func (istio *Istio) diagnose(ctx context.Context, namespace string, components []componentStatus) []componentStatus {
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+
+	for i, component := range components {
+		if component.Ready {
+			continue
+		}
+
+		deploy, err := istio.KubeClient.AppsV1().Deployments(namespace).Get(ctx, component.Name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		pods, err := istio.KubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			continue
+		}
+
+		if reasons := podProblems(pods.Items); len(reasons) > 0 {
+			components[i].Message = fmt.Sprintf("%s (%s)", component.Message, strings.Join(reasons, "; "))
+		}
+	}
+
+	return components
+}
+
+// deploymentComponents returns the readiness of the deployments, a component
+// of the given name is reported as missing when there are none
+func deploymentComponents(name string, deploys []appsv1.Deployment) []componentStatus {
+	if len(deploys) == 0 && name != "" {
+		return []componentStatus{{Name: name, Message: "deployment not found"}}
+	}
+
+	components := make([]componentStatus, 0, len(deploys))
+	for _, deploy := range deploys {
+		ready, msg := deploymentReady(deploy)
+		components = append(components, componentStatus{Name: deploy.Name, Ready: ready, Message: msg})
+	}
+
+	return components
+}
+
+// deploymentReady reports whether the rollout of the deployment completed
+func deploymentReady(deploy appsv1.Deployment) (bool, string) {
+	var desired int32 = 1
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+
+	msg := fmt.Sprintf("%d/%d replicas available", deploy.Status.AvailableReplicas, desired)
+	if deploy.Status.ObservedGeneration < deploy.Generation {
+		return false, msg + ", rollout pending"
+	}
+
+	ready := deploy.Status.UpdatedReplicas >= desired && deploy.Status.AvailableReplicas >= desired
+	return ready, msg
+}
+
+// webhookComponent returns the readiness of a webhook configuration, which is
+// ready once istiod has patched it with its CA bundle
+func webhookComponent(name string, patched bool) componentStatus {
+	if !patched {
+		return componentStatus{Name: name, Message: "CA bundle not injected yet"}
+	}
+
+	return componentStatus{Name: name, Ready: true, Message: "CA bundle injected"}
+}
+
+// podProblems lists the reasons keeping the containers of the pods from running
+func podProblems(pods []corev1.Pod) []string {
+	var reasons []string
+	for _, pod := range pods {
+		for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if waiting := cs.State.Waiting; waiting != nil && waiting.Reason != "" {
+				reasons = append(reasons, fmt.Sprintf("pod %s: %s %s", pod.Name, cs.Name, waiting.Reason))
+			}
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+				reasons = append(reasons, fmt.Sprintf("pod %s: %s", pod.Name, cond.Message))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// expectedComponents returns the components deployed by the options: those
+// of the profile, overridden by the overlay and then by the --set values like
+// istioctl does. The overlay has been validated by the options
+func expectedComponents(opts installOptions) expectedControlPlane {
+	profile := profileComponents[opts.Profile]
+
+	istiod := profile.istiod
+	gateways := map[string][]gatewayComponent{
+		"ingressGateways": {{Name: "istio-ingressgateway", Enabled: &profile.ingress}},
+		"egressGateways":  {{Name: "istio-egressgateway", Enabled: &profile.egress}},
+	}
+
+	overlay := overlayComponents{}
+	if err := yaml.Unmarshal([]byte(opts.Overlay), &overlay); err == nil {
+		if enabled := overlay.Spec.Components.Pilot.Enabled; enabled != nil {
+			istiod = *enabled
+		}
+		gateways["ingressGateways"] = mergeGateways(gateways["ingressGateways"], overlay.Spec.Components.IngressGateways)
+		gateways["egressGateways"] = mergeGateways(gateways["egressGateways"], overlay.Spec.Components.EgressGateways)
+	}
+
+	if enabled, ok := opts.Set["components.pilot.enabled"]; ok {
+		istiod = enabled == "true"
+	}
+
+	keys := make([]string, 0, len(opts.Set))
+	for key := range opts.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		match := setGatewayPattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(match[2])
+		gateway := gatewayComponent{}
+		if match[3] == "name" {
+			gateway.Name = opts.Set[key]
+		} else {
+			enabled := opts.Set[key] == "true"
+			gateway.Enabled = &enabled
+		}
+
+		overrides := make([]gatewayComponent, index+1)
+		overrides[index] = gateway
+		gateways[match[1]] = mergeGateways(gateways[match[1]], overrides)
+	}
+
+	expected := expectedControlPlane{Istiod: istiod}
+	for _, kind := range []string{"ingressGateways", "egressGateways"} {
+		for _, gateway := range gateways[kind] {
+			if gateway.Enabled != nil && *gateway.Enabled && gateway.Name != "" {
+				expected.Gateways = append(expected.Gateways, gateway.Name)
+			}
+		}
+	}
+
+	return expected
+}
+
+// mergeGateways overrides the names and the enablement of the gateways with
+// the ones set at the same index, the gateways without enablement aren't
+// deployed
+func mergeGateways(gateways, overrides []gatewayComponent) []gatewayComponent {
+	merged := make([]gatewayComponent, len(gateways))
+	copy(merged, gateways)
+
+	for i, override := range overrides {
+		if i == len(merged) {
+			merged = append(merged, gatewayComponent{})
+		}
+		if override.Name != "" {
+			merged[i].Name = override.Name
+		}
+		if override.Enabled != nil {
+			merged[i].Enabled = override.Enabled
+		}
+	}
+
+	return merged
+}
+
+func hasComponent(components []componentStatus, name string) bool {
+	for _, component := range components {
+		if component.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatComponentStatus renders the readiness of the components for an event
+func formatComponentStatus(components []componentStatus) string {
+	sorted := make([]componentStatus, len(components))
+	copy(sorted, components)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return !sorted[i].Ready && sorted[j].Ready
+	})
+
+	lines := make([]string, 0, len(sorted))
+	for _, component := range sorted {
+		state := "not ready"
+		if component.Ready {
+			state = "ready"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s, %s", component.Name, state, component.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,165 @@
+package istio
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_deploymentReady(t *testing.T) {
+	replicas := int32(2)
+
+	tests := []struct {
+		name   string
+		deploy appsv1.Deployment
+		want   bool
+	}{
+		{
+			name: "all replicas available",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 1},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 2, AvailableReplicas: 2},
+			},
+			want: true,
+		},
+		{
+			name: "replicas unavailable",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 1},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 2, AvailableReplicas: 1},
+			},
+			want: false,
+		},
+		{
+			name: "rollout not observed yet",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, msg := deploymentReady(tt.deploy); got != tt.want {
+				t.Errorf("deploymentReady() = %v (%s), want %v", got, msg, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expectedComponents(t *testing.T) {
+	overlayHeader := "apiVersion: install.istio.io/v1alpha1\nkind: IstioOperator\nspec:\n  components:\n"
+
+	tests := []struct {
+		name string
+		opts installOptions
+		want expectedControlPlane
+	}{
+		{
+			name: "demo profile",
+			opts: installOptions{Profile: "demo"},
+			want: expectedControlPlane{Istiod: true, Gateways: []string{"istio-ingressgateway", "istio-egressgateway"}},
+		},
+		{
+			name: "minimal profile",
+			opts: installOptions{Profile: "minimal"},
+			want: expectedControlPlane{Istiod: true},
+		},
+		{
+			name: "empty profile",
+			opts: installOptions{Profile: "empty"},
+			want: expectedControlPlane{},
+		},
+		{
+			name: "remote profile",
+			opts: installOptions{Profile: "remote"},
+			want: expectedControlPlane{},
+		},
+		{
+			name: "disabled egress gateway",
+			opts: installOptions{Profile: "demo", Set: map[string]string{"components.egressGateways[0].enabled": "false"}},
+			want: expectedControlPlane{Istiod: true, Gateways: []string{"istio-ingressgateway"}},
+		},
+		{
+			name: "overlay disabling pilot",
+			opts: installOptions{Profile: "default", Overlay: overlayHeader + "    pilot:\n      enabled: false\n"},
+			want: expectedControlPlane{Gateways: []string{"istio-ingressgateway"}},
+		},
+		{
+			name: "overlay adding a gateway",
+			opts: installOptions{
+				Profile: "default",
+				Overlay: overlayHeader + "    ingressGateways:\n    - name: istio-ingressgateway\n      enabled: true\n    - name: internal-gateway\n      enabled: true\n",
+			},
+			want: expectedControlPlane{Istiod: true, Gateways: []string{"istio-ingressgateway", "internal-gateway"}},
+		},
+		{
+			name: "gateway on top of the empty profile",
+			opts: installOptions{Profile: "empty", Set: map[string]string{"components.ingressGateways[0].enabled": "true"}},
+			want: expectedControlPlane{Gateways: []string{"istio-ingressgateway"}},
+		},
+		{
+			name: "values overriding the overlay",
+			opts: installOptions{
+				Profile: "minimal",
+				Overlay: overlayHeader + "    pilot:\n      enabled: false\n",
+				Set:     map[string]string{"components.pilot.enabled": "true", "components.egressGateways[0].enabled": "true", "components.egressGateways[0].name": "egress"},
+			},
+			want: expectedControlPlane{Istiod: true, Gateways: []string{"egress"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedComponents(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expectedComponents() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_podProblems(t *testing.T) {
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istiod-5d8b7c9f4-x2x7k"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "discovery", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway-6c8d9b7f5-9qk2p"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Message: "0/1 nodes are available: 1 Insufficient cpu."},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		"pod istiod-5d8b7c9f4-x2x7k: discovery ImagePullBackOff",
+		"pod istio-ingressgateway-6c8d9b7f5-9qk2p: 0/1 nodes are available: 1 Insufficient cpu.",
+	}
+	if got := podProblems(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("podProblems() = %v, want %v", got, want)
+	}
+}
+
+func Test_formatComponentStatus(t *testing.T) {
+	components := []componentStatus{
+		{Name: "istiod", Ready: true, Message: "1/1 replicas available"},
+		{Name: "istio-ingressgateway", Message: "deployment not found"},
+	}
+
+	want := "istio-ingressgateway: not ready, deployment not found\nistiod: ready, 1/1 replicas available"
+	if got := formatComponentStatus(components); got != want {
+		t.Errorf("formatComponentStatus() = %q, want %q", got, want)
+	}
+}
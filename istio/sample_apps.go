@@ -90,10 +90,10 @@ func (istio *Istio) LoadToMesh(namespace string, service string, remove bool) er
 	if deploy.ObjectMeta.Labels == nil {
 		deploy.ObjectMeta.Labels = map[string]string{}
 	}
-	deploy.ObjectMeta.Labels["istio-injection"] = "enabled"
+	deploy.ObjectMeta.Labels[injectionLabel] = "enabled"
 
 	if remove {
-		delete(deploy.ObjectMeta.Labels, "istio-injection")
+		delete(deploy.ObjectMeta.Labels, injectionLabel)
 	}
 
 	_, err = istio.KubeClient.AppsV1().Deployments(namespace).Update(context.TODO(), deploy, metav1.UpdateOptions{})
@@ -105,7 +105,10 @@ func (istio *Istio) LoadToMesh(namespace string, service string, remove bool) er
 }
 
 // LoadNamespaceToMesh is used to mark namespaces for automatic sidecar injection (or not)
-func (istio *Istio) LoadNamespaceToMesh(namespace string, remove bool) error {
+//
+// If a revision is given then the namespace is bound to that control plane revision
+// using the istio.io/rev label instead of the istio-injection label
+func (istio *Istio) LoadNamespaceToMesh(namespace, revision string, remove bool) error {
 	if istio.KubeClient == nil {
 		return ErrNilClient
 	}
@@ -118,10 +121,18 @@ func (istio *Istio) LoadNamespaceToMesh(namespace string, remove bool) error {
 	if ns.ObjectMeta.Labels == nil {
 		ns.ObjectMeta.Labels = map[string]string{}
 	}
-	ns.ObjectMeta.Labels["istio-injection"] = "enabled"
+
+	if revision == "" {
+		ns.ObjectMeta.Labels[injectionLabel] = "enabled"
+		delete(ns.ObjectMeta.Labels, revisionLabel)
+	} else {
+		ns.ObjectMeta.Labels[revisionLabel] = revision
+		delete(ns.ObjectMeta.Labels, injectionLabel)
+	}
 
 	if remove {
-		delete(ns.ObjectMeta.Labels, "istio-injection")
+		delete(ns.ObjectMeta.Labels, injectionLabel)
+		delete(ns.ObjectMeta.Labels, revisionLabel)
 	}
 
 	_, err = istio.KubeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
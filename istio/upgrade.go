@@ -0,0 +1,114 @@
+package istio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/status"
+	"github.com/layer5io/meshery-istio/internal/config"
+)
+
+// upgradeIstio upgrades the installed control plane in place to the given
+// version. The installed version is read from the default revision running
+// in the cluster and upgrades skipping more than one minor version are
+// refused. istioctl needs the options the control plane was installed with,
+// they are read from the request like for the install. In-place upgrades
+// can't be undone, deleting one is refused
+func (istio *Istio) upgradeIstio(ctx context.Context, opID string, del bool, version string, opts installOptions) (string, error) {
+	st := status.Installing
+
+	if del {
+		return status.Removing, ErrUpgradeIstio(fmt.Errorf("in-place upgrades can't be undone, use a canary upgrade to be able to roll back"))
+	}
+	if opts.Revision != "" {
+		return st, ErrUpgradeIstio(fmt.Errorf("in-place upgrades apply to the default revision, use a canary upgrade to install revision %s", opts.Revision))
+	}
+	if istio.KubeClient == nil {
+		return st, ErrNilClient
+	}
+
+	planes, err := istio.discoverControlPlanes(ctx)
+	if err != nil {
+		return st, ErrUpgradeIstio(err)
+	}
+
+	current := installedVersion(planes)
+	if err := checkUpgradePath(current, version); err != nil {
+		return st, ErrUpgradeIstio(err)
+	}
+
+	namespace := istio.findControlPlaneNamespace(ctx, "")
+	opts = opts.withNamespace(namespace)
+
+	overlayFile, err := writeOverlay(opts.Overlay)
+	if err != nil {
+		return st, ErrUpgradeIstio(err)
+	}
+	if overlayFile != "" {
+		defer func() {
+			_ = os.Remove(overlayFile)
+		}()
+	}
+
+	istio.streamProgress(opID, "Running pre-flight checks", fmt.Sprintf("Checking whether Istio %s can be upgraded to %s", current, version))
+	precheck := append([]string{"x", "precheck"}, opts.args(overlayFile)...)
+	if err := istio.execIstioCtl(ctx, opID, version, precheck...); err != nil {
+		return st, ErrUpgradeIstio(err)
+	}
+
+	istio.streamProgress(opID, fmt.Sprintf("Upgrading to Istio %s", version), fmt.Sprintf("Upgrading the control plane in %s from %s to %s in place", namespace, current, version))
+	upgrade := append([]string{"upgrade"}, opts.args(overlayFile)...)
+	if err := istio.execIstioCtl(ctx, opID, version, append(upgrade, "--skip-confirmation")...); err != nil {
+		return st, ErrUpgradeIstio(err)
+	}
+
+	if err := istio.verifyInstall(ctx, opID, version, namespace, opts); err != nil {
+		return st, err
+	}
+	istio.refreshMeshSpec(ctx)
+
+	return status.Installed, nil
+}
+
+// installedVersion returns the release of the default revision of the
+// control plane, none if it isn't running
+func installedVersion(planes []controlPlane) string {
+	for _, plane := range planes {
+		if plane.Revision == defaultRevision {
+			return strings.TrimSuffix(plane.Version, "-distroless")
+		}
+	}
+
+	return status.None
+}
+
+// checkUpgradePath makes sure that target is a newer release of the same
+// major version which is at most one minor version ahead of current
+func checkUpgradePath(current, target string) error {
+	if current == "" || current == status.None {
+		return fmt.Errorf("unable to determine the installed version of istio")
+	}
+
+	cur, err := config.ParseReleaseVersion(current)
+	if err != nil {
+		return err
+	}
+	tar, err := config.ParseReleaseVersion(target)
+	if err != nil {
+		return err
+	}
+
+	if cur.Major != tar.Major {
+		return fmt.Errorf("upgrading across major versions from %s to %s is not supported", current, target)
+	}
+	if !cur.Less(tar) {
+		return fmt.Errorf("%s is not newer than the installed version %s", target, current)
+	}
+	if tar.Minor-cur.Minor > 1 {
+		return fmt.Errorf("upgrading from %s to %s skips more than one minor version", current, target)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package istio
+
+import (
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/status"
+)
+
+func Test_checkUpgradePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		wantErr bool
+	}{
+		{
+			name:    "patch upgrade",
+			current: "1.8.0",
+			target:  "1.8.1",
+			wantErr: false,
+		},
+		{
+			name:    "minor upgrade",
+			current: "1.7.6",
+			target:  "1.8.1",
+			wantErr: false,
+		},
+		{
+			name:    "skips a minor version",
+			current: "1.6.14",
+			target:  "1.8.1",
+			wantErr: true,
+		},
+		{
+			name:    "downgrade",
+			current: "1.8.1",
+			target:  "1.7.6",
+			wantErr: true,
+		},
+		{
+			name:    "same version",
+			current: "1.8.1",
+			target:  "1.8.1",
+			wantErr: true,
+		},
+		{
+			name:    "not installed",
+			current: status.None,
+			target:  "1.8.1",
+			wantErr: true,
+		},
+		{
+			name:    "release without patch number",
+			current: "1.9",
+			target:  "1.10.0",
+			wantErr: false,
+		},
+		{
+			name:    "release candidate of the installed release",
+			current: "1.9.0",
+			target:  "1.9.0-rc.1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			current: "latest",
+			target:  "1.8.1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkUpgradePath(tt.current, tt.target); (err != nil) != tt.wantErr {
+				t.Errorf("checkUpgradePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_installedVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		planes []controlPlane
+		want   string
+	}{
+		{
+			name: "no control plane",
+			want: status.None,
+		},
+		{
+			name:   "default revision",
+			planes: []controlPlane{{Revision: "1-8-1", Version: "1.8.1"}, {Revision: defaultRevision, Version: "1.7.6-distroless"}},
+			want:   "1.7.6",
+		},
+		{
+			name:   "only canary revisions",
+			planes: []controlPlane{{Revision: "1-8-1", Version: "1.8.1"}},
+			want:   status.None,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := installedVersion(tt.planes); got != tt.want {
+				t.Errorf("installedVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
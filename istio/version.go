@@ -0,0 +1,40 @@
+package istio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+	"gopkg.in/yaml.v2"
+)
+
+// requestedVersion returns the istio release requested through the version
+// field of the custom body, the latest release supported by the operation
+// by default. Releases the operation doesn't support are rejected
+func requestedVersion(op *adapter.Operation, body string) (string, error) {
+	if op == nil || len(op.Versions) == 0 {
+		return "", ErrInvalidVersion(fmt.Errorf("no istio releases are available"))
+	}
+
+	requested := struct {
+		Version string `yaml:"version"`
+	}{}
+	if strings.TrimSpace(body) != "" {
+		// Malformed documents are reported by the parsing of the operation options
+		_ = yaml.Unmarshal([]byte(body), &requested)
+	}
+
+	if requested.Version == "" {
+		return string(op.Versions[0]), nil
+	}
+
+	supported := make([]string, 0, len(op.Versions))
+	for _, v := range op.Versions {
+		if strings.TrimPrefix(string(v), "v") == strings.TrimPrefix(requested.Version, "v") {
+			return string(v), nil
+		}
+		supported = append(supported, string(v))
+	}
+
+	return "", ErrInvalidVersion(fmt.Errorf("unknown istio release %q, supported releases are: %s", requested.Version, strings.Join(supported, ", ")))
+}
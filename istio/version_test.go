@@ -0,0 +1,63 @@
+package istio
+
+import (
+	"testing"
+
+	"github.com/layer5io/meshery-adapter-library/adapter"
+)
+
+func Test_requestedVersion(t *testing.T) {
+	op := &adapter.Operation{
+		Versions: []adapter.Version{"1.8.1", "1.8.0", "1.7.6"},
+	}
+
+	tests := []struct {
+		name    string
+		op      *adapter.Operation
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "latest release by default",
+			op:   op,
+			body: "",
+			want: "1.8.1",
+		},
+		{
+			name: "requested release",
+			op:   op,
+			body: `{"version": "1.7.6", "profile": "minimal"}`,
+			want: "1.7.6",
+		},
+		{
+			name: "requested release with prefix",
+			op:   op,
+			body: "version: v1.8.0",
+			want: "1.8.0",
+		},
+		{
+			name:    "unknown release",
+			op:      op,
+			body:    "version: 1.6.0",
+			wantErr: true,
+		},
+		{
+			name:    "no releases",
+			op:      &adapter.Operation{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := requestedVersion(tt.op, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("requestedVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("requestedVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -76,6 +76,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Refresh the istio versions offered by the operations in the background
+	go config.RefreshOperations(cfg, config.OperationsRefreshInterval, func(err error) {
+		log.Warn(err)
+	})
+
 	service := &grpc.Service{}
 	err = cfg.GetObject(adapter.ServerKey, service)
 	if err != nil {